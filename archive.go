@@ -0,0 +1,88 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// waybackAvailabilityURL is the endpoint of the Internet Archive's Wayback Machine availability API.
+var waybackAvailabilityURL = "https://archive.org/wayback/available"
+
+// waybackResponse is the response of the Wayback Machine availability API.
+type waybackResponse struct {
+	ArchivedSnapshots struct {
+		Closest *struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Status    string `json:"status"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// isGone reports whether status means that a source no longer exists.
+func isGone(status int) bool {
+	return status == http.StatusNotFound || status == http.StatusGone
+}
+
+// lookupArchiveURL returns the URL of the Wayback Machine snapshot of urlStr closest to at,
+// or an empty string if the page was never archived. at may be zero to get the latest snapshot.
+func lookupArchiveURL(ctx context.Context, customClient *http.Client, header http.Header, urlStr string, at time.Time) (string, error) {
+	client := &http.Client{Timeout: sourceFetchTimeout}
+	if customClient != nil {
+		client.Transport = customClient.Transport
+	}
+
+	query := url.Values{"url": {urlStr}}
+	if !at.IsZero() {
+		query.Set("timestamp", at.UTC().Format("20060102150405"))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, waybackAvailabilityURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", ierrors.Wrap(err, "failed to create Wayback Machine request")
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", ierrors.Wrap(err, "failed to query the Wayback Machine")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d from the Wayback Machine", resp.StatusCode)
+	}
+
+	var wr waybackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&wr); err != nil {
+		return "", ierrors.Wrap(err, "failed to decode Wayback Machine response")
+	}
+	closest := wr.ArchivedSnapshots.Closest
+	if closest == nil || !closest.Available || closest.URL == "" {
+		return "", nil
+	}
+	return closest.URL, nil
+}
+
+// attachArchiveURL sets the ArchiveURL of attr, whose source at sourceURL no longer exists, to
+// the closest Wayback Machine snapshot of sourceURL. sourceURL is the origin URL the source was
+// fetched from, since attr.URL may still be a grounding redirect URL, which is never archived.
+func (c *Client) attachArchiveURL(ctx context.Context, attr *GroundingAttribution, sourceURL string) error {
+	release, err := c.hostLimiter.acquire(ctx, waybackAvailabilityURL)
+	if err != nil {
+		return err
+	}
+	defer release()
+	archiveURL, err := lookupArchiveURL(ctx, c.resolverClient, c.resolverHeader, sourceURL, attr.AccessedAt())
+	if err != nil {
+		return err
+	}
+	attr.ArchiveURL = archiveURL
+	return nil
+}
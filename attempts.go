@@ -0,0 +1,63 @@
+package search
+
+import (
+	"context"
+	"time"
+)
+
+// AttemptReason explains why the client sent a generation request.
+type AttemptReason string
+
+// Constants for AttemptReason
+const (
+	// AttemptReasonInitial is the first request made for a call.
+	AttemptReasonInitial AttemptReason = "initial"
+	// AttemptReasonDomainDiversity is a re-query made because too few distinct domains were cited.
+	AttemptReasonDomainDiversity AttemptReason = "domain_diversity"
+	// AttemptReasonLanguage is a re-query made because the answer was not written in the
+	// requested language (see GenerationParams.ResponseLanguage).
+	AttemptReasonLanguage AttemptReason = "language"
+)
+
+// Attempt records one generation request the client made on the caller's behalf.
+type Attempt struct {
+	// Model is the model the request was sent to.
+	Model string `json:"model"`
+
+	// Reason explains why the request was made.
+	Reason AttemptReason `json:"reason"`
+
+	// Duration is the wall-clock time of the request.
+	Duration time.Duration `json:"duration"`
+
+	// Succeeded reports whether the request returned a usable response.
+	Succeeded bool `json:"succeeded"`
+
+	// Error is the error message of a failed request.
+	Error string `json:"error,omitempty"`
+}
+
+// requestModel returns the model name a request with params is sent to.
+func (c *Client) requestModel(params *GenerationParams) string {
+	if params != nil && params.ModelName != "" {
+		return params.ModelName
+	}
+	return c.defaultModel
+}
+
+// generateAttempt runs generate and appends the outcome to attempts.
+func (c *Client) generateAttempt(ctx context.Context, params *GenerationParams, reason AttemptReason, attempts *[]Attempt) (*Response, error) {
+	start := time.Now()
+	resp, err := c.generate(ctx, params)
+	a := Attempt{
+		Model:     c.requestModel(params),
+		Reason:    reason,
+		Duration:  time.Since(start),
+		Succeeded: err == nil,
+	}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	*attempts = append(*attempts, a)
+	return resp, err
+}
@@ -0,0 +1,191 @@
+package search
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// attributionIDLength is the number of hex characters kept from the URL hash.
+const attributionIDLength = 16
+
+// trackingQueryPrefixes lists query parameter prefixes that do not identify content
+// and are dropped when canonicalizing URLs.
+var trackingQueryPrefixes = []string{"utm_", "fbclid", "gclid", "mc_cid", "mc_eid"}
+
+// CanonicalURL normalizes rawURL so that different spellings of the same page compare equal.
+// It lower-cases the scheme and host, removes default ports, fragments, tracking query
+// parameters, and trailing slashes, and sorts the remaining query parameters.
+// If rawURL cannot be parsed, it is returned trimmed but otherwise unchanged.
+func CanonicalURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.User = nil
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			for _, prefix := range trackingQueryPrefixes {
+				if strings.HasPrefix(strings.ToLower(key), prefix) {
+					query.Del(key)
+					break
+				}
+			}
+		}
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			for _, value := range query[key] {
+				parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			}
+		}
+		u.RawQuery = strings.Join(parts, "&")
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// AttributionID returns the deterministic identifier for a source URL: a truncated
+// SHA-256 hash of its canonical form. The same page yields the same ID across runs.
+// An empty URL yields an empty ID.
+func AttributionID(rawURL string) string {
+	if strings.TrimSpace(rawURL) == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(CanonicalURL(rawURL)))
+	return hex.EncodeToString(sum[:])[:attributionIDLength]
+}
+
+// assignAttributionIDs sets the ID of every attribution from its current URL.
+// It must run after URL resolution so that IDs are derived from the original source URL.
+func assignAttributionIDs(attrs []GroundingAttribution) {
+	for i := range attrs {
+		attrs[i].ID = attributionID(attrs[i])
+	}
+}
+
+// attributionID returns the ID of attr. Grounding redirect URLs differ between responses citing
+// the same page, so unresolved sources get no ID.
+func attributionID(attr GroundingAttribution) string {
+	if hostOf(attr.URL) == groundingRedirectHost {
+		return ""
+	}
+	return AttributionID(attr.URL)
+}
+
+// setRetrievedAt sets the RetrievedAt of every attribution to t, unless t is zero.
+func setRetrievedAt(attrs []GroundingAttribution, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	for i := range attrs {
+		retrievedAt := t
+		attrs[i].RetrievedAt = &retrievedAt
+	}
+}
+
+// DedupedAttributions returns the response's grounding attributions with duplicates merged.
+// Attributions are duplicates if their URLs have the same canonical form (see CanonicalURL),
+// which in practice requires resolved URLs (see WithNoRedirection). The merged attribution
+// keeps the position, title, and domain of the first occurrence and the segments of all
+// occurrences, ordered by position; a segment cited more than once keeps its highest
+// confidence score. Attributions without a URL are kept as they are.
+// The response itself is not modified.
+func (r *Response) DedupedAttributions() []GroundingAttribution {
+	if r == nil || len(r.GroundingAttributions) == 0 {
+		return nil
+	}
+	out := make([]GroundingAttribution, 0, len(r.GroundingAttributions))
+	index := make(map[string]int, len(r.GroundingAttributions))
+	for _, attr := range r.GroundingAttributions {
+		attr.Segments = append([]GroundingAttributionSegment(nil), attr.Segments...)
+		key := CanonicalURL(attr.URL)
+		if key == "" {
+			out = append(out, attr)
+			continue
+		}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(out)
+			out = append(out, attr)
+			continue
+		}
+		merged := &out[i]
+		if merged.Title == "" {
+			merged.Title = attr.Title
+		}
+		if merged.Domain == "" {
+			merged.Domain = attr.Domain
+		}
+		merged.Segments = mergeSegments(merged.Segments, attr.Segments)
+	}
+	return out
+}
+
+// mergeSegments adds the segments of src to dst. Segments covering the same range of the same
+// part are merged, keeping the highest confidence score. The result is ordered by position.
+func mergeSegments(dst, src []GroundingAttributionSegment) []GroundingAttributionSegment {
+	for _, seg := range src {
+		found := false
+		for i := range dst {
+			if dst[i].PartIndex == seg.PartIndex && dst[i].StartIndex == seg.StartIndex && dst[i].EndIndex == seg.EndIndex {
+				if seg.ConfidenceScore > dst[i].ConfidenceScore {
+					dst[i].ConfidenceScore = seg.ConfidenceScore
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, seg)
+		}
+	}
+	sort.SliceStable(dst, func(i, j int) bool {
+		if dst[i].PartIndex != dst[j].PartIndex {
+			return dst[i].PartIndex < dst[j].PartIndex
+		}
+		return dst[i].StartIndex < dst[j].StartIndex
+	})
+	return dst
+}
+
+// filterByConfidence removes the attributions whose segments are all scored below minConfidence.
+// Segments without a score do not count as below the threshold, so attributions from models
+// that do not report confidence scores are kept.
+func filterByConfidence(attrs []GroundingAttribution, minConfidence float32) []GroundingAttribution {
+	kept := attrs[:0:0]
+	for _, attr := range attrs {
+		confident := len(attr.Segments) == 0
+		for _, seg := range attr.Segments {
+			if seg.ConfidenceScore == 0 || seg.ConfidenceScore >= minConfidence {
+				confident = true
+				break
+			}
+		}
+		if confident {
+			kept = append(kept, attr)
+		}
+	}
+	return kept
+}
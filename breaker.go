@@ -0,0 +1,109 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// circuitBreaker stops sending requests after consecutive server errors.
+// After the cooldown, a single trial request is let through (half-open); its outcome
+// decides whether the circuit closes again or stays open for another cooldown.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	open     bool
+	trial    bool // a half-open trial request is in flight
+}
+
+// newCircuitBreaker creates a circuitBreaker, or returns nil if threshold is not positive.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a request may be sent, and whether it is the half-open trial request of
+// an open circuit. A nil circuitBreaker always allows.
+func (b *circuitBreaker) allow() (trial, ok bool) {
+	if b == nil {
+		return false, true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return false, true
+	}
+	if b.trial || time.Since(b.openedAt) < b.cooldown {
+		return false, false
+	}
+	b.trial = true
+	return true, true
+}
+
+// record updates the breaker with the outcome of a request that allow let through; trial is
+// the value allow returned for it. Only server errors reported by the API count as failures,
+// and only the trial request can close an open circuit. Cancellations and timeouts of the
+// caller's context say nothing about the service, so they are ignored.
+func (b *circuitBreaker) record(trial bool, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if trial {
+		b.trial = false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	responded, failed := apiOutcome(err)
+	if !responded {
+		// Without a response, e.g., after a network error, nothing is known about the service.
+		return
+	}
+	switch {
+	case b.open && !trial:
+		// The request was sent before the circuit opened; only the trial decides.
+	case failed:
+		b.failures++
+		if b.open || b.failures >= b.threshold {
+			b.open = true
+			b.openedAt = time.Now()
+		}
+	default:
+		// The service responded without a server error, so it is reachable.
+		b.failures = 0
+		b.open = false
+	}
+}
+
+// apiOutcome reports whether err, the outcome of an API call, came from a response of the
+// API, and whether that response was a server error: an HTTP 5xx response, or a gRPC Internal
+// or Unavailable status. A nil err is a successful response. Errors the client merely labels
+// with an API status, such as failures to reach the API, are not responses.
+func apiOutcome(err error) (responded, failed bool) {
+	if err == nil {
+		return true, false
+	}
+	var sdkErr genai.APIError
+	if errors.As(err, &sdkErr) {
+		return true, sdkErr.Code >= 500
+	}
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		code := grpcErr.GRPCStatus().Code()
+		return true, code == codes.Internal || code == codes.Unavailable
+	}
+	return false, false
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"google.golang.org/genai"
+)
+
+// CachedContentConfig describes content to cache with CreateCachedContent.
+type CachedContentConfig struct {
+	// ModelName is the model the cache is created for; requests using the cache must use the same
+	// model. If empty, the client's default model is used.
+	ModelName string
+
+	// DisplayName is a human-readable name for the cache.
+	DisplayName string
+
+	// SystemInstruction is a large, static instruction block (e.g., constraints sent with every
+	// grounded query) to cache.
+	SystemInstruction string
+
+	// Documents are reference texts to cache.
+	Documents []string
+
+	// Files are uploaded documents to cache (see UploadFile).
+	Files []File
+
+	// TTL is how long the cache is kept. If zero, the service default (one hour) applies.
+	TTL time.Duration
+}
+
+// CachedContent is content cached with CreateCachedContent.
+type CachedContent struct {
+	// Name is the resource name of the cache (e.g., "cachedContents/abc-123"),
+	// used with WithCachedContent and GenerationParams.CachedContent.
+	Name string `json:"name"`
+
+	// DisplayName is the human-readable name of the cache.
+	DisplayName string `json:"display_name,omitempty"`
+
+	// Model is the model the cache was created for.
+	Model string `json:"model"`
+
+	// ExpiresAt is when the cache expires.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateCachedContent caches a large, static system instruction or reference documents so they
+// are not sent, and billed in full, with every request. Requests use the cache via
+// WithCachedContent or GenerationParams.CachedContent. The client's tools (the Google Search Tool
+// and any configured tools and functions) are cached with the content, as the API does not allow
+// requests that use a cache to set tools.
+func (c *Client) CreateCachedContent(ctx context.Context, cfg CachedContentConfig) (*CachedContent, error) {
+	if cfg.TTL < 0 {
+		return nil, ierrors.Wrapf(ErrInvalidParameter, "cached content TTL cannot be negative, got %v", cfg.TTL)
+	}
+	if strings.TrimSpace(cfg.SystemInstruction) == "" && len(cfg.Documents) == 0 && len(cfg.Files) == 0 {
+		return nil, ierrors.Wrap(ErrInvalidParameter, "cached content must have a system instruction, documents, or files")
+	}
+	model := c.defaultModel
+	if cfg.ModelName != "" {
+		if err := validateModelName(cfg.ModelName); err != nil {
+			return nil, err
+		}
+		model = cfg.ModelName
+	}
+	model = normalizeModelName(model, c.config.Backend)
+
+	var parts []*genai.Part
+	for _, doc := range cfg.Documents {
+		parts = append(parts, genai.NewPartFromText(doc))
+	}
+	for _, f := range cfg.Files {
+		if f.URI == "" || f.MIMEType == "" {
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "file %q must have a URI and a MIME type", f.DisplayName)
+		}
+		parts = append(parts, genai.NewPartFromURI(f.URI, f.MIMEType))
+	}
+	sdkConfig := &genai.CreateCachedContentConfig{
+		DisplayName: cfg.DisplayName,
+		TTL:         cfg.TTL,
+		Tools:       c.defaultGenContentConfig.Tools,
+	}
+	if strings.TrimSpace(cfg.SystemInstruction) != "" {
+		sdkConfig.SystemInstruction = genai.NewContentFromText(cfg.SystemInstruction, genai.RoleUser)
+	}
+	if len(parts) > 0 {
+		sdkConfig.Contents = []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+	}
+
+	ctx, cancel, err := c.bindToClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	cached, err := c.genaiClient.Caches.Create(ctx, model, sdkConfig)
+	if err != nil {
+		return nil, ierrors.Wrap(c.closedError(err), "failed to create cached content")
+	}
+	return &CachedContent{
+		Name:        cached.Name,
+		DisplayName: cached.DisplayName,
+		Model:       cached.Model,
+		ExpiresAt:   cached.ExpireTime,
+	}, nil
+}
+
+// DeleteCachedContent deletes the cache with the given name before it expires.
+func (c *Client) DeleteCachedContent(ctx context.Context, name string) error {
+	ctx, cancel, err := c.bindToClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	if _, err := c.genaiClient.Caches.Delete(ctx, name, nil); err != nil {
+		return ierrors.Wrapf(c.closedError(err), "failed to delete cached content %s", name)
+	}
+	return nil
+}
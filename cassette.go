@@ -0,0 +1,228 @@
+package search
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// redactedValue replaces secrets in recorded request URLs.
+const redactedValue = "REDACTED"
+
+// cassetteMode selects whether a cassetteTransport records or replays traffic.
+type cassetteMode int
+
+const (
+	cassetteRecord cassetteMode = iota
+	cassetteReplay
+)
+
+// recordedInteraction is one request and its response, stored as a JSON file in a cassette
+// directory. Bodies that are not valid UTF-8 are stored base64-encoded in BodyBytes instead.
+type recordedInteraction struct {
+	Request struct {
+		Method    string `json:"method"`
+		URL       string `json:"url"`
+		Body      string `json:"body,omitempty"`
+		BodyBytes []byte `json:"body_bytes,omitempty"`
+	} `json:"request"`
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body,omitempty"`
+		BodyBytes  []byte      `json:"body_bytes,omitempty"`
+	} `json:"response"`
+}
+
+// cassette is a directory of recorded HTTP interactions (see WithRecording and WithReplay).
+// Each interaction is stored in a file named after a hash of the request's method, URL, and
+// body, with a sequence number, so that identical requests are replayed in recorded order.
+// API keys are removed from recordings, and request headers are not recorded at all.
+type cassette struct {
+	dir  string
+	mode cassetteMode
+
+	mu     sync.Mutex
+	counts map[string]int // number of requests seen per request hash
+}
+
+// newCassette returns the cassette configured by cfg, or nil if none is.
+func newCassette(cfg *ClientConfig) *cassette {
+	switch {
+	case cfg.RecordingDir != "":
+		return &cassette{dir: cfg.RecordingDir, mode: cassetteRecord, counts: make(map[string]int)}
+	case cfg.ReplayDir != "":
+		return &cassette{dir: cfg.ReplayDir, mode: cassetteReplay, counts: make(map[string]int)}
+	}
+	return nil
+}
+
+// wrap returns a copy of client, or of a default client if client is nil, whose requests are
+// recorded to or replayed from the cassette.
+func (c *cassette) wrap(client *http.Client) *http.Client {
+	wrapped := &http.Client{}
+	if client != nil {
+		*wrapped = *client
+	}
+	base := wrapped.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped.Transport = &cassetteTransport{cassette: c, base: base}
+	return wrapped
+}
+
+// cassetteTransport sends requests through a cassette. base is only used when recording.
+type cassetteTransport struct {
+	*cassette
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, ierrors.Wrap(err, "failed to read request body for cassette")
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	// Store compressed requests (see WithRequestCompression) readably, and match them by content.
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, ierrors.Wrap(err, "failed to decompress request body for cassette")
+		}
+		if body, err = io.ReadAll(zr); err != nil {
+			return nil, ierrors.Wrap(err, "failed to decompress request body for cassette")
+		}
+	}
+
+	redacted := redactURL(req.URL)
+	sum := sha256.Sum256([]byte(req.Method + " " + redacted + "\n" + string(body)))
+	key := hex.EncodeToString(sum[:8])
+	t.mu.Lock()
+	seq := t.counts[key]
+	t.counts[key]++
+	t.mu.Unlock()
+
+	if t.mode == cassetteReplay {
+		return t.replay(req, key, seq)
+	}
+	return t.record(req, key, seq, redacted, body)
+}
+
+// replay returns the seq-th recorded response for the request hash key, or the last one
+// recorded if the request was made more often during replay than during recording.
+func (t *cassetteTransport) replay(req *http.Request, key string, seq int) (*http.Response, error) {
+	var data []byte
+	for ; seq >= 0; seq-- {
+		var err error
+		data, err = os.ReadFile(t.path(key, seq))
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, ierrors.Wrap(err, "failed to read cassette")
+		}
+	}
+	if seq < 0 {
+		return nil, ierrors.Wrapf(ErrNoRecording, "%s %s", req.Method, redactURL(req.URL))
+	}
+
+	var rec recordedInteraction
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, ierrors.Wrapf(err, "failed to parse cassette %s", t.path(key, seq))
+	}
+	body := rec.Response.BodyBytes
+	if body == nil {
+		body = []byte(rec.Response.Body)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Response.StatusCode, http.StatusText(rec.Response.StatusCode)),
+		StatusCode:    rec.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Response.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// record sends the request and stores it with its response as the seq-th interaction of key.
+func (t *cassetteTransport) record(req *http.Request, key string, seq int, redactedURL string, body []byte) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, ierrors.Wrap(err, "failed to read response body for cassette")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	var rec recordedInteraction
+	rec.Request.Method = req.Method
+	rec.Request.URL = redactedURL
+	rec.Request.Body, rec.Request.BodyBytes = encodeCassetteBody(body)
+	rec.Response.StatusCode = resp.StatusCode
+	rec.Response.Header = resp.Header.Clone()
+	rec.Response.Header.Del("Set-Cookie")
+	rec.Response.Body, rec.Response.BodyBytes = encodeCassetteBody(respBody)
+
+	data, err := json.MarshalIndent(&rec, "", "  ")
+	if err != nil {
+		return nil, ierrors.Wrap(err, "failed to encode cassette")
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return nil, ierrors.Wrap(err, "failed to create cassette directory")
+	}
+	if err := os.WriteFile(t.path(key, seq), data, 0o644); err != nil {
+		return nil, ierrors.Wrap(err, "failed to write cassette")
+	}
+	return resp, nil
+}
+
+// path returns the file of the seq-th interaction with the request hash key.
+func (c *cassette) path(key string, seq int) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%s-%d.json", key, seq))
+}
+
+// encodeCassetteBody returns body as a string if it is valid UTF-8, or as bytes otherwise.
+func encodeCassetteBody(body []byte) (string, []byte) {
+	if utf8.Valid(body) {
+		return string(body), nil
+	}
+	return "", body
+}
+
+// redactURL returns u as a string with the API key query parameter redacted.
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	if !q.Has("key") {
+		return u.String()
+	}
+	q.Set("key", redactedValue)
+	redacted := *u
+	redacted.RawQuery = q.Encode()
+	return redacted.String()
+}
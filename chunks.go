@@ -0,0 +1,93 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// approxCharsPerToken is the rough number of characters per token used by Response.Chunks
+// to turn a token budget into a chunk length without calling the tokenizer.
+const approxCharsPerToken = 4
+
+// TextChunk is a piece of a response's generated text together with the sources that support it.
+type TextChunk struct {
+	// Text is the chunk's text, with surrounding whitespace trimmed.
+	Text string `json:"text"`
+
+	// StartIndex and EndIndex are the byte offsets of Text within Response.GeneratedText,
+	// using the same indexing as GroundingAttributionSegment.
+	StartIndex int `json:"start_index"`
+	EndIndex   int `json:"end_index"`
+
+	// GroundingAttributions lists the attributions with at least one segment overlapping the chunk.
+	// Each attribution's Segments is restricted to the overlapping segments.
+	GroundingAttributions []GroundingAttribution `json:"grounding_attributions,omitempty"`
+}
+
+// Chunks splits the generated text into chunks of roughly at most maxTokens tokens,
+// preferring paragraph, line, sentence, and word boundaries, and attaches to each chunk the
+// grounding attributions whose segments overlap it. This keeps citation metadata intact when
+// grounded answers are ingested into a vector store.
+// The token count is estimated from the text length. If maxTokens is not positive, the whole
+// text is returned as a single chunk.
+func (r *Response) Chunks(maxTokens int) []TextChunk {
+	if r == nil || strings.TrimSpace(r.GeneratedText) == "" {
+		return nil
+	}
+	text := r.GeneratedText
+
+	var chunks []TextChunk
+	start := 0
+	for start < len(text) {
+		end := len(text)
+		if maxTokens > 0 {
+			end = chunkEnd(text, start, maxTokens*approxCharsPerToken)
+		}
+		if chunk, ok := r.newTextChunk(start, end); ok {
+			chunks = append(chunks, chunk)
+		}
+		start = end
+	}
+	return chunks
+}
+
+// chunkEnd returns the byte offset at which the chunk starting at start should end,
+// so that the chunk holds at most maxChars runes.
+func chunkEnd(text string, start, maxChars int) int {
+	rest := text[start:]
+	if utf8.RuneCountInString(rest) <= maxChars {
+		return len(text)
+	}
+	window := []rune(rest)[:maxChars]
+	return start + len(string(window[:findSplitPoint(window)]))
+}
+
+// newTextChunk builds the chunk for text[start:end], trimming surrounding whitespace.
+// It returns false if the range holds only whitespace.
+func (r *Response) newTextChunk(start, end int) (TextChunk, bool) {
+	s := r.GeneratedText[start:end]
+	trimmedLeft := strings.TrimLeftFunc(s, unicode.IsSpace)
+	start += len(s) - len(trimmedLeft)
+	trimmed := strings.TrimRightFunc(trimmedLeft, unicode.IsSpace)
+	if trimmed == "" {
+		return TextChunk{}, false
+	}
+	end = start + len(trimmed)
+
+	chunk := TextChunk{Text: trimmed, StartIndex: start, EndIndex: end}
+	for _, attr := range r.GroundingAttributions {
+		var segments []GroundingAttributionSegment
+		for _, seg := range attr.Segments {
+			if seg.StartIndex < end && seg.EndIndex > start {
+				segments = append(segments, seg)
+			}
+		}
+		if len(segments) == 0 {
+			continue
+		}
+		attr.Segments = segments
+		chunk.GroundingAttributions = append(chunk.GroundingAttributions, attr)
+	}
+	return chunk, true
+}
@@ -0,0 +1,230 @@
+/*
+Package citations formats grounding attributions as references in common citation
+styles (APA, MLA, Chicago) or with a user-supplied Go template.
+
+Web sources returned by grounded search rarely carry an author or publication date,
+so references are built from the page title, the site (domain), the URL, and the
+access date. The access date is taken from GroundingAttribution.AccessedAt and falls
+back to Formatter.AccessedAt.
+
+Basic Usage:
+
+	refs, err := citations.Format(citations.StyleAPA, response.GroundingAttributions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, ref := range refs {
+		fmt.Println(ref)
+	}
+
+Custom templates receive a Citation:
+
+	tmpl := template.Must(template.New("ref").Parse(`{{.Title}} <{{.URL}}> ({{.Accessed.Format "2006-01-02"}})`))
+	refs, err := citations.Formatter{Template: tmpl}.FormatAll(response.GroundingAttributions)
+*/
+package citations
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	search "github.com/cnosuke/go-gemini-grounded-search"
+)
+
+// ErrUnknownStyle is returned when a Formatter has neither a known Style nor a Template.
+var ErrUnknownStyle = errors.New("citations: unknown citation style")
+
+// Style is a citation style.
+type Style string
+
+// Constants for Style
+const (
+	// StyleAPA is the American Psychological Association style (7th edition).
+	StyleAPA Style = "apa"
+	// StyleMLA is the Modern Language Association style (9th edition).
+	StyleMLA Style = "mla"
+	// StyleChicago is the Chicago Manual of Style (17th edition, notes and bibliography).
+	StyleChicago Style = "chicago"
+)
+
+// Citation is the data a reference is built from. It is also the data passed to custom templates.
+type Citation struct {
+	// Title is the title of the source, or its site if the title is unknown.
+	Title string
+
+	// Site is the domain of the source, without a leading "www.".
+	Site string
+
+	// URL is the source's URL.
+	URL string
+
+	// Accessed is when the source was accessed. It is the zero time if unknown.
+	Accessed time.Time
+
+	// Attribution is the attribution the citation was built from.
+	Attribution search.GroundingAttribution
+}
+
+// Formatter formats attributions as references.
+type Formatter struct {
+	// Style selects a built-in citation style. It is ignored if Template is set.
+	Style Style
+
+	// Template, if set, renders each reference from a Citation.
+	Template *template.Template
+
+	// AccessedAt is the access date used for attributions that do not record one
+	// (see GroundingAttribution.AccessedAt). If zero, such references have no access date.
+	AccessedAt time.Time
+}
+
+// Format formats attrs in the given built-in style, one reference per attribution.
+func Format(style Style, attrs []search.GroundingAttribution) ([]string, error) {
+	return Formatter{Style: style}.FormatAll(attrs)
+}
+
+// FormatAll formats attrs, one reference per attribution, in order.
+func (f Formatter) FormatAll(attrs []search.GroundingAttribution) ([]string, error) {
+	refs := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		ref, err := f.Format(attr)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// Format formats a single attribution.
+func (f Formatter) Format(attr search.GroundingAttribution) (string, error) {
+	c := f.newCitation(attr)
+	if f.Template != nil {
+		var b strings.Builder
+		if err := f.Template.Execute(&b, c); err != nil {
+			return "", fmt.Errorf("citations: failed to execute template: %w", err)
+		}
+		return b.String(), nil
+	}
+
+	switch Style(strings.ToLower(string(f.Style))) {
+	case StyleAPA:
+		return formatAPA(c), nil
+	case StyleMLA:
+		return formatMLA(c), nil
+	case StyleChicago:
+		return formatChicago(c), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownStyle, f.Style)
+	}
+}
+
+// newCitation builds the Citation for attr.
+func (f Formatter) newCitation(attr search.GroundingAttribution) Citation {
+	c := Citation{
+		Title:       strings.TrimSpace(attr.Title),
+		Site:        siteOf(attr),
+		URL:         attr.URL,
+		Accessed:    attr.AccessedAt(),
+		Attribution: attr,
+	}
+	if c.Title == "" {
+		c.Title = c.Site
+	}
+	if c.Accessed.IsZero() {
+		c.Accessed = f.AccessedAt
+	}
+	return c
+}
+
+// formatAPA formats c as an APA reference to a web page without author or date:
+// Title. (n.d.). Site. Retrieved January 2, 2006, from URL
+func formatAPA(c Citation) string {
+	var b strings.Builder
+	b.WriteString(terminate(c.Title))
+	b.WriteString(" (n.d.).")
+	if c.Site != "" && c.Site != c.Title {
+		b.WriteString(" " + terminate(c.Site))
+	}
+	switch {
+	case c.URL != "" && !c.Accessed.IsZero():
+		fmt.Fprintf(&b, " Retrieved %s, from %s", c.Accessed.Format("January 2, 2006"), c.URL)
+	case c.URL != "":
+		b.WriteString(" " + c.URL)
+	}
+	return b.String()
+}
+
+// formatMLA formats c as an MLA works-cited entry:
+// "Title." Site, URL. Accessed 2 Jan. 2006.
+func formatMLA(c Citation) string {
+	var b strings.Builder
+	b.WriteString(`"` + terminate(c.Title) + `"`)
+	var container []string
+	if c.Site != "" && c.Site != c.Title {
+		container = append(container, c.Site)
+	}
+	if c.URL != "" {
+		container = append(container, strings.TrimPrefix(strings.TrimPrefix(c.URL, "https://"), "http://"))
+	}
+	if len(container) > 0 {
+		b.WriteString(" " + terminate(strings.Join(container, ", ")))
+	}
+	if !c.Accessed.IsZero() {
+		fmt.Fprintf(&b, " Accessed %d %s %d.", c.Accessed.Day(), mlaMonth(c.Accessed.Month()), c.Accessed.Year())
+	}
+	return b.String()
+}
+
+// formatChicago formats c as a Chicago bibliography entry:
+// "Title." Site. Accessed January 2, 2006. URL.
+func formatChicago(c Citation) string {
+	var b strings.Builder
+	b.WriteString(`"` + terminate(c.Title) + `"`)
+	if c.Site != "" && c.Site != c.Title {
+		b.WriteString(" " + terminate(c.Site))
+	}
+	if !c.Accessed.IsZero() {
+		fmt.Fprintf(&b, " Accessed %s.", c.Accessed.Format("January 2, 2006"))
+	}
+	if c.URL != "" {
+		b.WriteString(" " + c.URL + ".")
+	}
+	return b.String()
+}
+
+// terminate appends a period to s unless it already ends with terminal punctuation.
+func terminate(s string) string {
+	if s == "" || strings.HasSuffix(s, ".") || strings.HasSuffix(s, "?") || strings.HasSuffix(s, "!") {
+		return s
+	}
+	return s + "."
+}
+
+// mlaMonth returns the MLA abbreviation of m.
+func mlaMonth(m time.Month) string {
+	switch m {
+	case time.May, time.June, time.July:
+		return m.String()
+	case time.September:
+		return "Sept."
+	default:
+		return m.String()[:3] + "."
+	}
+}
+
+// siteOf returns the domain of the source of attr, without a leading "www.".
+// The attribution's Domain is used when set; otherwise the host of its URL.
+func siteOf(attr search.GroundingAttribution) string {
+	site := attr.Domain
+	if site == "" {
+		if u, err := url.Parse(attr.URL); err == nil {
+			site = u.Hostname()
+		}
+	}
+	return strings.TrimPrefix(strings.ToLower(site), "www.")
+}
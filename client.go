@@ -32,9 +32,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
@@ -48,14 +49,30 @@ import (
 type Client struct {
 	config                  ClientConfig                 // Resolved configuration after applying options
 	genaiClient             *genai.Client                // Underlying client from the official Google AI Go SDK
-	httpClient              *http.Client                 // HTTP client for non-API requests like redirection resolving
+	keys                    *keyPool                     // SDK clients of every API key to rotate over; nil if only one key
+	httpClient              *http.Client                 // HTTP client for API requests, with proxy applied
+	resolverClient          *http.Client                 // HTTP client for redirection resolving; nil to use defaults
+	resolverHeader          http.Header                  // Headers sent with redirection-resolving requests
 	defaultModel            string                       // Default model name (e.g., "gemini-3.5-flash")
 	defaultGenContentConfig *genai.GenerateContentConfig // Default generation configuration
 	userAgent               string                       // Combined user-agent string
+	functions               map[string]Function          // Client-level functions keyed by name
+	scopes                  map[string]SearchScope       // Registered search scopes keyed by name
+	hostLimiter             *hostLimiter                 // Per-host concurrency limit for URL resolution and fetching
+	urlCache                *urlCache                    // Cache of resolved redirect URLs; nil if disabled
+	logger                  *slog.Logger                 // Structured logger for diagnostics; never nil
+	metrics                 MetricsRecorder              // Metrics sink; never nil
+	handler                 GenerateFunc                 // execute wrapped by the configured interceptors
+	rateLimiter             *rateLimiter                 // Client-side API rate limit; nil if disabled
+	breaker                 *circuitBreaker              // Circuit breaker for server errors; nil if disabled
+	closeCtx                context.Context              // Canceled when the client is closed
+	closeCancel             context.CancelFunc           // Cancels closeCtx
+	closeOnce               sync.Once                    // Guards Close
 }
 
 // NewClient creates and initializes a new Gemini API client.
-// apiKey is your Google AI API key.
+// apiKey is your Google AI API key. It must be empty when authenticating with OAuth
+// credentials via WithVertexAIProject.
 // opts are functional options to customize the client's behavior.
 func NewClient(ctx context.Context, apiKey string, opts ...ClientOption) (*Client, error) {
 	cfg, err := newDefaultClientConfig(apiKey)
@@ -71,12 +88,67 @@ func NewClient(ctx context.Context, apiKey string, opts ...ClientOption) (*Clien
 		return nil, err
 	}
 
+	userAgent := cfg.buildUserAgent()
 	sdkConfig := &genai.ClientConfig{
-		APIKey: cfg.APIKey,
+		APIKey:      cfg.APIKey,
+		Backend:     cfg.Backend.toSDK(),
+		Project:     cfg.Project,
+		Location:    cfg.Location,
+		Credentials: cfg.Credentials,
+		HTTPOptions: cfg.HTTPOptions.toSDK(),
+	}
+	if sdkConfig.HTTPOptions.Headers == nil {
+		sdkConfig.HTTPOptions.Headers = make(http.Header)
+	}
+	// Set before the SDK appends its own value, so the library's User-Agent is the one sent.
+	// An explicit User-Agent from WithHTTPOptions takes precedence.
+	if sdkConfig.HTTPOptions.Headers.Get("User-Agent") == "" {
+		sdkConfig.HTTPOptions.Headers.Set("User-Agent", userAgent)
+	}
+
+	httpClient, err := cfg.buildHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		sdkConfig.HTTPClient = httpClient
+	}
+
+	resolverClient, err := cfg.buildResolverClient(httpClient)
+	if err != nil {
+		return nil, err
+	}
+	// Built after the resolver client, so the API's TLS configuration stays out of URL resolution.
+	if httpClient, err = cfg.buildAPIClient(httpClient); err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		sdkConfig.HTTPClient = httpClient
+	}
+
+	if cas := newCassette(cfg); cas != nil {
+		shared := resolverClient == httpClient
+		httpClient = cas.wrap(httpClient)
+		if shared {
+			resolverClient = httpClient
+		} else {
+			resolverClient = cas.wrap(resolverClient)
+		}
+		sdkConfig.HTTPClient = httpClient
 	}
 
-	if cfg.HTTPClient != nil {
-		sdkConfig.HTTPClient = cfg.HTTPClient
+	// Compression applies to API requests only, on top of any recording, so that cassettes
+	// store request bodies uncompressed.
+	if cfg.RequestCompression {
+		sdkConfig.HTTPClient = compressRequests(sdkConfig.HTTPClient)
+	}
+
+	// The SDK adds OAuth credentials only to HTTP clients it creates, so wrap a custom one.
+	// Only API requests use the authenticated client; URL resolution keeps httpClient.
+	if cfg.Project != "" && sdkConfig.HTTPClient != nil {
+		if sdkConfig.HTTPClient, err = cfg.buildAuthenticatedClient(ctx, sdkConfig.HTTPClient); err != nil {
+			return nil, err
+		}
 	}
 
 	gClient, err := genai.NewClient(ctx, sdkConfig)
@@ -87,6 +159,17 @@ func NewClient(ctx context.Context, apiKey string, opts ...ClientOption) (*Clien
 		return nil, newAPIError(codes.Internal, "failed to create genai client", err)
 	}
 
+	keyClients := []*genai.Client{gClient}
+	for _, key := range cfg.APIKeys {
+		keyConfig := *sdkConfig
+		keyConfig.APIKey = key
+		kClient, err := genai.NewClient(ctx, &keyConfig)
+		if err != nil {
+			return nil, newAPIError(codes.Internal, "failed to create genai client for additional API key", err)
+		}
+		keyClients = append(keyClients, kClient)
+	}
+
 	var gConf genai.GenerateContentConfig
 
 	if cfg.DefaultTemperature != nil {
@@ -99,6 +182,15 @@ func NewClient(ctx context.Context, apiKey string, opts ...ClientOption) (*Clien
 	if cfg.DefaultTopP != nil {
 		gConf.TopP = cfg.DefaultTopP
 	}
+	if cfg.DefaultSeed != nil {
+		gConf.Seed = cfg.DefaultSeed
+	}
+	if cfg.DefaultPresencePenalty != nil {
+		gConf.PresencePenalty = cfg.DefaultPresencePenalty
+	}
+	if cfg.DefaultFrequencyPenalty != nil {
+		gConf.FrequencyPenalty = cfg.DefaultFrequencyPenalty
+	}
 	if cfg.DefaultMaxOutputTokens != nil {
 		gConf.MaxOutputTokens = *cfg.DefaultMaxOutputTokens
 	}
@@ -117,35 +209,84 @@ func NewClient(ctx context.Context, apiKey string, opts ...ClientOption) (*Clien
 		gConf.ThinkingConfig = cfg.DefaultThinkingConfig.toSDK()
 	}
 
+	if cfg.ResponseMIMEType != "" {
+		gConf.ResponseMIMEType = cfg.ResponseMIMEType
+	}
+
 	if cfg.DisableGoogleSearchToolGlobally {
 		gConf.Tools = nil
+	} else if cfg.EnterpriseWebSearch {
+		gConf.Tools = []*genai.Tool{
+			newEnterpriseWebSearchTool(),
+		}
 	} else {
 		gConf.Tools = []*genai.Tool{
 			newGoogleSearchRetrieverTool(),
 		}
 	}
 
+	if cfg.EnableURLContext {
+		gConf.Tools = append(gConf.Tools, newURLContextTool())
+	}
+
+	gConf.Tools = append(gConf.Tools, cfg.Tools...)
+
+	functions := make(map[string]Function, len(cfg.Functions))
+	if len(cfg.Functions) > 0 {
+		gConf.Tools = append(gConf.Tools, newFunctionTool(cfg.Functions))
+		for _, fn := range cfg.Functions {
+			functions[fn.Declaration.Name] = fn
+		}
+	}
+
+	scopes := make(map[string]SearchScope, len(cfg.SearchScopes))
+	for _, s := range cfg.SearchScopes {
+		scopes[s.Name] = s
+	}
+
 	client := &Client{
 		config:                  *cfg,
 		genaiClient:             gClient,
-		httpClient:              cfg.HTTPClient, // Use the configured client, or nil
+		keys:                    newKeyPool(keyClients),
+		httpClient:              httpClient, // Use the configured client (with proxy applied), or nil
+		resolverClient:          resolverClient,
+		resolverHeader:          cfg.ResolverOptions.header(userAgent),
 		defaultModel:            cfg.ModelName,
 		defaultGenContentConfig: &gConf,
+		userAgent:               userAgent,
+		functions:               functions,
+		scopes:                  scopes,
+		hostLimiter:             newHostLimiter(cfg.MaxConcurrentRequestsPerHost),
+		urlCache:                newURLCache(cfg.URLResolutionCache, cfg.URLResolutionCacheTTL),
+		logger:                  cfg.Logger,
+		metrics:                 cfg.MetricsRecorder,
+		rateLimiter:             newRateLimiter(cfg.RateLimit, cfg.RateLimitBurst),
+		breaker:                 newCircuitBreaker(cfg.CircuitBreakerThreshold, cfg.CircuitBreakerCooldown),
 	}
+	if client.logger == nil {
+		client.logger = slog.New(slog.DiscardHandler)
+	}
+	if client.metrics == nil {
+		client.metrics = noopMetricsRecorder{}
+	}
+	client.handler = chainInterceptors(cfg.Interceptors, client.execute)
+	client.closeCtx, client.closeCancel = context.WithCancel(context.Background())
 	return client, nil
 }
 
 // processGenaiResponse is a helper function to handle the response from genai.GenerateContent.
-func (c *Client) processGenaiResponse(ctx context.Context, genaiResp *genai.GenerateContentResponse, callErr error) (*Response, error) {
+// If resolveURLs is true, grounding redirect URLs are resolved to their original URLs.
+func (c *Client) processGenaiResponse(ctx context.Context, genaiResp *genai.GenerateContentResponse, callErr error, resolveURLs bool) (*Response, error) {
 	if callErr != nil {
 		s, ok := status.FromError(callErr)
 		if ok {
 			if s.Code() == codes.InvalidArgument && containsSafetyBlockDetails(s.Details()) {
-				return nil, newAPIError(s.Code(), s.Message(), ErrContentBlocked, s.Details()...)
+				blocked := &BlockedError{Stage: BlockStagePrompt, Message: s.Message()}
+				return nil, newAPIError(s.Code(), s.Message(), blocked, s.Details()...)
 			}
 			return nil, newAPIError(s.Code(), s.Message(), callErr, s.Details()...)
 		}
-		return nil, newAPIError(codes.Unknown, "genai API call failed", callErr)
+		return nil, newAPIErrorFromSDK(callErr, "genai API call failed")
 	}
 
 	if genaiResp == nil {
@@ -154,9 +295,16 @@ func (c *Client) processGenaiResponse(ctx context.Context, genaiResp *genai.Gene
 
 	// Based on user-provided SDK's types.go, PromptFeedback.BlockReason is a string.
 	if genaiResp.PromptFeedback != nil && genaiResp.PromptFeedback.BlockReason != genai.BlockedReasonUnspecified { // genai.BlockedReasonUnspecified is a string const from SDK
+		feedback := genaiResp.PromptFeedback
+		blocked := &BlockedError{
+			Stage:         BlockStagePrompt,
+			Reason:        string(feedback.BlockReason),
+			Message:       feedback.BlockReasonMessage,
+			SafetyRatings: newSafetyRatings(feedback.SafetyRatings),
+		}
 		return nil, newAPIError(codes.InvalidArgument,
-			fmt.Sprintf("prompt blocked due to %s: %s", genaiResp.PromptFeedback.BlockReason, genaiResp.PromptFeedback.BlockReasonMessage),
-			ErrContentBlocked)
+			fmt.Sprintf("prompt blocked due to %s: %s", feedback.BlockReason, feedback.BlockReasonMessage),
+			blocked)
 	}
 
 	if len(genaiResp.Candidates) == 0 {
@@ -166,46 +314,57 @@ func (c *Client) processGenaiResponse(ctx context.Context, genaiResp *genai.Gene
 	candidate := genaiResp.Candidates[0]
 	// Based on user-provided SDK's types.go, FinishReason is a string.
 	if candidate.FinishReason == genai.FinishReasonSafety {
-		var safetyDetails string
-		if len(candidate.SafetyRatings) > 0 {
-			safetyDetails = fmt.Sprintf(" (Ratings: %v)", candidate.SafetyRatings)
+		blocked := &BlockedError{
+			Stage:         BlockStageCandidate,
+			Reason:        string(candidate.FinishReason),
+			Message:       candidate.FinishMessage,
+			SafetyRatings: newSafetyRatings(candidate.SafetyRatings),
 		}
 		return nil, newAPIError(codes.FailedPrecondition,
-			fmt.Sprintf("content generation stopped due to safety filters%s", safetyDetails),
-			ErrContentBlocked)
+			"content generation stopped due to safety filters",
+			blocked)
 	}
 
 	if candidate.Content == nil || len(candidate.Content.Parts) == 0 {
 		return nil, ErrNoContentGenerated
 	}
 
-	var generatedTextBuilder strings.Builder
-	for _, part := range candidate.Content.Parts {
-		if part.Text != "" {
-			generatedTextBuilder.WriteString(part.Text)
-		}
-	}
-
-	grounding, err := extractGroundingMetadata(candidate.GroundingMetadata)
+	candidates, err := newCandidateResults(genaiResp.Candidates)
 	if err != nil {
-		return nil, ierrors.Wrapf(err, "failed to extract grounding metadata")
+		return nil, err
 	}
 
-	// If redirection is disabled, resolve the original URL.
-	if c.config.NoRedirection {
-		c.resolveGroundingURLs(ctx, grounding)
+	// If redirection is disabled, resolve the original URLs of every candidate in one pass.
+	if resolveURLs {
+		c.resolveCandidateURLs(ctx, candidates)
+	}
+	for _, cand := range candidates {
+		assignAttributionIDs(cand.GroundingAttributions)
+		setRetrievedAt(cand.GroundingAttributions, genaiResp.CreateTime)
 	}
 
 	// Your application's Response struct (from your types.go)
 	libResponse := &Response{
-		GeneratedText:         generatedTextBuilder.String(),
-		GroundingAttributions: grounding,
-		SearchSuggestions:     []string{}, // TODO: Populate if new SDK provides similar info
-		PromptFeedback:        genaiResp.PromptFeedback,
+		GeneratedText:         candidates[0].Text,
+		ThoughtSummary:        candidates[0].ThoughtSummary,
+		GroundingAttributions: candidates[0].GroundingAttributions,
+		FinishReason:          candidates[0].FinishReason,
+		FinishMessage:         candidates[0].FinishMessage,
+		SafetyRatings:         newSafetyRatings(candidate.SafetyRatings),
+		Logprobs:              candidates[0].Logprobs,
+		URLContextMetadata:    extractURLContextMetadata(candidate.URLContextMetadata),
+		SearchSuggestions:     extractSearchSuggestions(candidate.GroundingMetadata),
+		SearchEntryPointHTML:  extractSearchEntryPointHTML(candidate.GroundingMetadata),
+		GroundingDetails:      newGroundingDetails(candidate.GroundingMetadata),
+		PromptFeedback:        newPromptFeedback(genaiResp.PromptFeedback),
 		Candidates:            genaiResp.Candidates,
 		RawResponse:           genaiResp,
 	}
 
+	if len(candidates) > 1 {
+		libResponse.AllCandidates = candidates
+	}
+
 	if libResponse.GeneratedText == "" && len(libResponse.GroundingAttributions) == 0 {
 		return nil, ErrNoContentGenerated
 	}
@@ -213,6 +372,62 @@ func (c *Client) processGenaiResponse(ctx context.Context, genaiResp *genai.Gene
 	return libResponse, nil
 }
 
+// newCandidateResults converts every API candidate into a CandidateResult.
+func newCandidateResults(candidates []*genai.Candidate) ([]CandidateResult, error) {
+	results := make([]CandidateResult, 0, len(candidates))
+	for i, candidate := range candidates {
+		result := CandidateResult{Index: i}
+		if candidate == nil {
+			results = append(results, result)
+			continue
+		}
+		result.FinishReason = FinishReason(candidate.FinishReason)
+		result.FinishMessage = candidate.FinishMessage
+		result.Logprobs = newLogprobs(candidate)
+		if candidate.Content != nil {
+			var text, thoughts strings.Builder
+			for _, part := range candidate.Content.Parts {
+				switch {
+				case part == nil || part.Text == "":
+				case part.Thought:
+					thoughts.WriteString(part.Text)
+				default:
+					text.WriteString(part.Text)
+				}
+			}
+			result.Text = text.String()
+			result.ThoughtSummary = thoughts.String()
+		}
+		grounding, err := extractGroundingMetadata(candidate.GroundingMetadata)
+		if err != nil {
+			return nil, ierrors.Wrapf(err, "failed to extract grounding metadata of candidate %d", i)
+		}
+		result.GroundingAttributions = grounding
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// resolveCandidateURLs resolves the grounding redirect URLs of all candidates together,
+// so the resolution runs in a single worker pool rather than once per candidate.
+func (c *Client) resolveCandidateURLs(ctx context.Context, candidates []CandidateResult) {
+	if len(candidates) == 1 {
+		c.resolveGroundingURLs(ctx, candidates[0].GroundingAttributions)
+		return
+	}
+	var all []GroundingAttribution
+	for _, cand := range candidates {
+		all = append(all, cand.GroundingAttributions...)
+	}
+	c.resolveGroundingURLs(ctx, all)
+	offset := 0
+	for i := range candidates {
+		n := len(candidates[i].GroundingAttributions)
+		copy(candidates[i].GroundingAttributions, all[offset:offset+n])
+		offset += n
+	}
+}
+
 // containsSafetyBlockDetails checks if error details indicate a safety block.
 // Details type is []any as per status.Details().
 func containsSafetyBlockDetails(details []any) bool {
@@ -234,22 +449,17 @@ func containsSafetyBlockDetails(details []any) bool {
 }
 
 // ListAvailableModels returns a list of available Gemini model names.
+// Use ListModels for token limits and supported actions.
 func (c *Client) ListAvailableModels(ctx context.Context) ([]string, error) {
-	var models []string
-	for m, err := range c.genaiClient.Models.All(ctx) {
-		if err != nil {
-			return nil, ierrors.Wrapf(err, "failed to list models")
-		}
-		if m == nil {
-			continue
-		}
-		models = append(models, m.Name)
+	infos, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(models) == 0 {
-		return nil, errors.New("no models available")
+	models := make([]string, len(infos))
+	for i, m := range infos {
+		models[i] = m.Name
 	}
-
 	return models, nil
 }
 
@@ -267,25 +477,91 @@ func (c *Client) GenerateGroundedContent(ctx context.Context, query string) (*Re
 }
 
 // GenerateGroundedContentWithParams sends a query to the Gemini API with per-request parameters.
+// The call passes through any interceptors registered with WithInterceptor.
+// It returns ErrClientClosed if the client has been closed.
 func (c *Client) GenerateGroundedContentWithParams(ctx context.Context, params *GenerationParams) (*Response, error) {
+	ctx, cancel, err := c.bindToClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	return c.generateWithHooks(ctx, params)
+}
+
+// execute runs query moderation, generation, and post-generation policies for a request.
+// It is the innermost GenerateFunc of the interceptor chain.
+func (c *Client) execute(ctx context.Context, params *GenerationParams) (*Response, error) {
+	scope, err := c.lookupScope(params)
+	if err != nil {
+		return nil, err
+	}
+	if scope != nil {
+		params = applyScope(params, scope)
+	}
+
+	if c.config.QueryModeration && params != nil && (params.question() != "" || params.Audio != nil) {
+		if err := c.moderateQuery(ctx, params); err != nil {
+			return nil, err
+		}
+	}
+
+	model, err := c.routeModel(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if model != "" {
+		routed := *params
+		routed.ModelName = model
+		params = &routed
+	}
+
+	var attempts []Attempt
+	resp, err := c.generateAttempt(ctx, params, AttemptReasonInitial, &attempts)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.config.MinDistinctDomains > 0 {
+		resp = c.enforceDomainDiversity(ctx, params, resp, &attempts)
+	}
+	// Structured output is not prose, so its language is not checked.
+	mimeType := c.config.ResponseMIMEType
+	if params.ResponseMIMEType != "" {
+		mimeType = params.ResponseMIMEType
+	}
+	if params.ResponseLanguage != "" && mimeType != "application/json" {
+		resp = c.enforceResponseLanguage(ctx, params, resp, &attempts)
+	}
+
+	resp.Attempts = attempts
+	return resp, nil
+}
+
+// generate performs a single grounded generation request, including any function-call round-trips.
+func (c *Client) generate(ctx context.Context, params *GenerationParams) (*Response, error) {
 	if params == nil {
 		return nil, ierrors.Wrapf(ErrInvalidParameter, "generation parameters cannot be nil")
 	}
-	if params.Prompt == "" {
-		return nil, ierrors.Wrapf(ErrInvalidParameter, "prompt within generation parameters cannot be empty")
+	if err := validatePrompt(params); err != nil {
+		return nil, err
 	}
 
-	modelName := c.config.ModelName
+	model := c.defaultModel
 	if params.ModelName != "" {
-		modelName = params.ModelName
+		model = params.ModelName
 	}
-	if modelName == "" {
+	if model == "" {
 		return nil, newAPIError(codes.InvalidArgument, "model name is not configured", ErrInvalidModelName)
 	}
+	if err := validateModelName(model); err != nil {
+		return nil, err
+	}
+	model = normalizeModelName(model, c.config.Backend)
 
-	model := c.defaultModel
-	if params.ModelName != "" {
-		model = params.ModelName
+	scope, err := c.lookupScope(params)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply generation parameters by modifying a copy of the model's GenerationConfig
@@ -302,6 +578,33 @@ func (c *Client) GenerateGroundedContentWithParams(ctx context.Context, params *
 	if params.TopP != nil {
 		currentConfig.TopP = params.TopP
 	}
+	if params.Seed != nil {
+		currentConfig.Seed = params.Seed
+	}
+	if params.PresencePenalty != nil {
+		if err := validatePenalty("presence penalty", *params.PresencePenalty); err != nil {
+			return nil, err
+		}
+		currentConfig.PresencePenalty = params.PresencePenalty
+	}
+	if params.FrequencyPenalty != nil {
+		if err := validatePenalty("frequency penalty", *params.FrequencyPenalty); err != nil {
+			return nil, err
+		}
+		currentConfig.FrequencyPenalty = params.FrequencyPenalty
+	}
+	if params.Logprobs != nil {
+		if !params.ResponseLogprobs {
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "logprobs requires response logprobs to be enabled")
+		}
+		if *params.Logprobs < 0 || *params.Logprobs > maxLogprobs {
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "logprobs must be between 0 and %d, got %d", maxLogprobs, *params.Logprobs)
+		}
+		currentConfig.Logprobs = params.Logprobs
+	}
+	if params.ResponseLogprobs {
+		currentConfig.ResponseLogprobs = true
+	}
 
 	if params.MaxOutputTokens != nil {
 		currentConfig.MaxOutputTokens = *params.MaxOutputTokens
@@ -330,11 +633,146 @@ func (c *Client) GenerateGroundedContentWithParams(ctx context.Context, params *
 	if params.ThinkingConfig != nil {
 		currentConfig.ThinkingConfig = params.ThinkingConfig.toSDK()
 	}
+	if params.ThinkingBudgetTokens != nil {
+		if *params.ThinkingBudgetTokens < -1 {
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "thinking budget must be non-negative or -1 (dynamic), got %d", *params.ThinkingBudgetTokens)
+		}
+		thinking := &genai.ThinkingConfig{}
+		if currentConfig.ThinkingConfig != nil {
+			tc := *currentConfig.ThinkingConfig
+			thinking = &tc
+		}
+		budget := *params.ThinkingBudgetTokens
+		thinking.ThinkingBudget = &budget
+		thinking.ThinkingLevel = ""
+		currentConfig.ThinkingConfig = thinking
+	}
+
+	if params.ResponseMIMEType != "" {
+		currentConfig.ResponseMIMEType = params.ResponseMIMEType
+	}
+
+	if params.ResolveURLs != nil && !*params.ResolveURLs && c.needsResolvedDomains(params, scope) {
+		return nil, ierrors.Wrap(ErrInvalidParameter, "URL resolution cannot be disabled for a request whose sources are filtered by domain")
+	}
+	if params.MinConfidence != nil && (*params.MinConfidence < 0 || *params.MinConfidence > 1) {
+		return nil, ierrors.Wrapf(ErrInvalidParameter, "min confidence must be between 0.0 and 1.0, got %f", *params.MinConfidence)
+	}
+	if params.ResponseLanguage != "" {
+		if err := validateResponseLanguage(params.ResponseLanguage); err != nil {
+			return nil, err
+		}
+	}
+	var freshSince time.Time
+	if params.Freshness != nil {
+		now := time.Now()
+		if err := params.Freshness.validate(now); err != nil {
+			return nil, err
+		}
+		freshSince = params.Freshness.cutoff(now)
+		// The search time range filter is only supported by the Gemini API; on Vertex AI,
+		// freshness relies on the prompt and the publication date check.
+		if c.config.Backend != BackendVertexAI {
+			currentConfig.Tools = withSearchTimeRange(currentConfig.Tools, freshSince, now)
+		}
+	}
+	location := c.config.UserLocation
+	if params.UserLocation != nil {
+		if err := params.UserLocation.validate(); err != nil {
+			return nil, err
+		}
+		location = params.UserLocation
+	}
+
+	if err := validateDomains("allowed", params.AllowedDomains); err != nil {
+		return nil, err
+	}
+	if err := validateDomains("blocked", params.BlockedDomains); err != nil {
+		return nil, err
+	}
+	if err := validateDomains("restricted", params.RestrictToSites); err != nil {
+		return nil, err
+	}
+	if err := validateDomains("excluded", params.ExcludeSites); err != nil {
+		return nil, err
+	}
+	// Excluding domains from the search itself is only supported by Vertex AI.
+	if len(params.ExcludeSites) > 0 && c.config.Backend == BackendVertexAI {
+		excluded := make([]string, len(params.ExcludeSites))
+		for i, site := range params.ExcludeSites {
+			excluded[i] = normalizeDomain(site)
+		}
+		currentConfig.Tools = withSearchExcludedDomains(currentConfig.Tools, excluded)
+	}
 
-	contents := []*genai.Content{
-		genai.NewContentFromText(params.Prompt, genai.RoleUser),
+	if len(params.ContextURLs) > 0 {
+		if err := validateContextURLs(params.ContextURLs); err != nil {
+			return nil, err
+		}
+		if !c.config.EnableURLContext {
+			// Copy the tools slice so the client's default configuration is left untouched.
+			currentConfig.Tools = append(append([]*genai.Tool{}, currentConfig.Tools...), newURLContextTool())
+		}
 	}
 
+	if len(params.Tools) > 0 {
+		for _, t := range params.Tools {
+			if t == nil {
+				return nil, ierrors.Wrap(ErrInvalidParameter, "tool cannot be nil")
+			}
+		}
+		// Copy the tools slice so the client's default configuration is left untouched.
+		currentConfig.Tools = append(append([]*genai.Tool{}, currentConfig.Tools...), params.Tools...)
+	}
+
+	functions := c.functions
+	if len(params.Functions) > 0 {
+		if err := validateFunctions(params.Functions, c.functions); err != nil {
+			return nil, err
+		}
+		functions = make(map[string]Function, len(c.functions)+len(params.Functions))
+		for name, fn := range c.functions {
+			functions[name] = fn
+		}
+		for _, fn := range params.Functions {
+			functions[fn.Declaration.Name] = fn
+		}
+		// Copy the tools slice so the client's default configuration is left untouched.
+		currentConfig.Tools = append(append([]*genai.Tool{}, currentConfig.Tools...), newFunctionTool(params.Functions))
+	}
+
+	cachedContent := c.config.CachedContent
+	if params.CachedContent != "" {
+		cachedContent = params.CachedContent
+	}
+	if cachedContent != "" {
+		// Requests using cached content cannot set tools; the cache carries the client's tools.
+		if len(currentConfig.Tools) != len(c.defaultGenContentConfig.Tools) {
+			return nil, ierrors.Wrap(ErrInvalidParameter, "per-request tools, functions, and context URLs cannot be used with cached content")
+		}
+		// Freshness restricts the search time range through the Google Search tool.
+		if params.Freshness != nil {
+			return nil, ierrors.Wrap(ErrInvalidParameter, "freshness cannot be used with cached content")
+		}
+		// On Vertex AI, excluded sites are removed from the search through the Google Search tool.
+		if len(params.ExcludeSites) > 0 {
+			return nil, ierrors.Wrap(ErrInvalidParameter, "excluded sites cannot be used with cached content")
+		}
+		currentConfig.CachedContent = cachedContent
+		currentConfig.Tools = nil
+	} else if params.disableSearch {
+		currentConfig.Tools = withoutSearchTools(currentConfig.Tools)
+	}
+
+	userContent, err := buildUserContent(params, location)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.InjectionGuard {
+		hardenUserContent(userContent)
+	}
+	contents := []*genai.Content{userContent}
+
 	var cancelFunc context.CancelFunc = func() {}
 	if c.config.RequestTimeout > 0 {
 		_, deadlineSet := ctx.Deadline()
@@ -344,15 +782,119 @@ func (c *Client) GenerateGroundedContentWithParams(ctx context.Context, params *
 	}
 	defer cancelFunc()
 
-	r, err := c.genaiClient.Models.GenerateContent(ctx, model, contents, &currentConfig)
+	trial, ok := c.breaker.allow()
+	if !ok {
+		c.logger.WarnContext(ctx, "gemini: circuit breaker open, failing fast", slog.String("model", model))
+		return nil, ErrCircuitOpen
+	}
+
+	start := time.Now()
+	logAttrs := []any{
+		slog.String("model", model),
+		slog.Int("prompt_len", len(params.question())),
+	}
+	// Prompt text is logged only once it has been redacted; it may contain personal data.
+	if c.config.PromptRedactor != nil {
+		logAttrs = append(logAttrs, slog.String("prompt", c.redactPrompt(params.question())))
+	}
+	c.logger.DebugContext(ctx, "gemini: generation request started", logAttrs...)
+
+	r, calls, partial, err := c.generateWithFunctions(ctx, model, contents, &currentConfig, functions, c.config.MaxFunctionCallRounds)
+	if err != nil && errors.Is(err, ErrFunctionCallLimitExceeded) {
+		// The API answered every round, so this settles a half-open trial like any other outcome.
+		c.breaker.record(trial, nil)
+		c.logger.WarnContext(ctx, "gemini: function call round limit exceeded",
+			slog.String("model", model),
+			slog.Int("function_calls", len(calls)),
+		)
+		c.metrics.RecordRequest(ctx, newRequestMetrics(model, time.Since(start), nil, err))
+		return nil, err
+	}
+	var aborted *streamAbortedError
+	if errors.As(err, &aborted) {
+		// The stream was aborted after the API had started responding, so it is reachable.
+		c.breaker.record(trial, nil)
+		c.metrics.RecordRequest(ctx, newRequestMetrics(model, time.Since(start), nil, aborted.err))
+		return nil, aborted.err
+	}
 
-	return c.processGenaiResponse(ctx, r, err)
+	resolveURLs := c.config.NoRedirection
+	if params.ResolveURLs != nil {
+		resolveURLs = *params.ResolveURLs
+	}
+	if c.needsResolvedDomains(params, scope) {
+		resolveURLs = true
+	}
+
+	// Only the API call decides the breaker's state, not processing of its response.
+	c.breaker.record(trial, err)
+	resp, err := c.processGenaiResponse(ctx, r, err, resolveURLs)
+	if err != nil {
+		c.logger.WarnContext(ctx, "gemini: generation request failed",
+			slog.String("model", model),
+			slog.Duration("duration", time.Since(start)),
+			slog.Any("error", err),
+		)
+		c.metrics.RecordRequest(ctx, newRequestMetrics(model, time.Since(start), nil, err))
+		return nil, err
+	}
+	resp.FunctionCalls = calls
+	resp.GroundingAttributions, resp.ExcludedAttributions = c.filterAttributions(params, scope, resp.GroundingAttributions)
+	if scope != nil {
+		resp.Scope = scope.Name
+	}
+	switch {
+	case c.config.EnrichSources || params.Freshness != nil:
+		c.processSources(ctx, resp.GroundingAttributions, c.enrichSource)
+	case c.config.ValidateSources:
+		c.processSources(ctx, resp.GroundingAttributions, c.validateSource)
+	}
+	if params.Freshness != nil {
+		resp.GroundingAttributions, resp.StaleAttributions = splitStaleAttributions(resp.GroundingAttributions, freshSince)
+	}
+	// The other candidates get the same filters, so that removed sources do not reappear
+	// through AllCandidates. Their sources are only fetched when Freshness needs publication dates.
+	if len(resp.AllCandidates) > 0 {
+		resp.AllCandidates[0].GroundingAttributions = resp.GroundingAttributions
+		for i := 1; i < len(resp.AllCandidates); i++ {
+			cand := &resp.AllCandidates[i]
+			cand.GroundingAttributions, _ = c.filterAttributions(params, scope, cand.GroundingAttributions)
+			if params.Freshness != nil {
+				c.processSources(ctx, cand.GroundingAttributions, c.enrichSource)
+				cand.GroundingAttributions, _ = splitStaleAttributions(cand.GroundingAttributions, freshSince)
+			}
+		}
+	}
+	if c.config.InjectionGuard {
+		resp.SecurityFlags = scanForInjection(resp)
+		if len(resp.SecurityFlags) > 0 {
+			c.logger.WarnContext(ctx, "gemini: possible prompt injection in grounded content",
+				slog.String("model", model),
+				slog.Int("flags", len(resp.SecurityFlags)),
+			)
+		}
+	}
+	if partial {
+		resp.Partial = true
+		c.logger.WarnContext(ctx, "gemini: soft timeout exceeded, returning partial response",
+			slog.String("model", model),
+			slog.Duration("duration", time.Since(start)),
+		)
+	}
+	c.metrics.RecordRequest(ctx, newRequestMetrics(model, time.Since(start), resp, nil))
+
+	c.logger.DebugContext(ctx, "gemini: generation request finished",
+		slog.String("model", model),
+		slog.Duration("duration", time.Since(start)),
+		slog.Any("response", resp),
+	)
+	return resp, nil
 }
 
 // resolveOriginURL resolves one level of redirection for a given URL.
 // It performs a single HEAD request to check if the URL redirects and returns
 // the redirect destination, or the original URL if no redirect is found.
-func resolveOriginURL(ctx context.Context, customClient *http.Client, urlStr string) (string, error) {
+func resolveOriginURL(ctx context.Context, customClient *http.Client, header http.Header, urlStr string) (string, error) {
 	// Use the provided custom client if available, otherwise create a dedicated client
 	var client *http.Client
 	if customClient != nil {
@@ -378,6 +920,9 @@ func resolveOriginURL(ctx context.Context, customClient *http.Client, urlStr str
 	if err != nil {
 		return "", ierrors.Wrapf(err, "failed to create request for %s", urlStr)
 	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -410,9 +955,10 @@ type urlResolveJob struct {
 
 // urlResolveResult represents the result of URL resolution
 type urlResolveResult struct {
-	index int
-	url   string
-	err   error
+	index  int
+	url    string
+	err    error
+	cached bool
 }
 
 // resolveGroundingURLs resolves redirect URLs to their original URLs using worker pattern
@@ -425,8 +971,19 @@ func (c *Client) resolveGroundingURLs(ctx context.Context, grounding []Grounding
 	resolveCtx, cancel := c.createResolveContext(ctx)
 	defer cancel()
 
+	start := time.Now()
+	var resolved, failed, cacheHits int
+	defer func() {
+		c.metrics.RecordURLResolution(ctx, URLResolutionMetrics{
+			Resolved:  resolved,
+			Failed:    failed,
+			CacheHits: cacheHits,
+			Duration:  time.Since(start),
+		})
+	}()
+
 	// Worker pattern implementation
-	const numWorkers = 8
+	numWorkers := min(c.config.URLResolutionConcurrency, len(grounding))
 	jobs := make(chan urlResolveJob, len(grounding))
 	results := make(chan urlResolveResult, len(grounding))
 
@@ -446,17 +1003,28 @@ func (c *Client) resolveGroundingURLs(ctx context.Context, grounding []Grounding
 	close(jobs)
 
 	// Collect results
-	for range jobCount {
+	for i := range jobCount {
 		select {
 		case result := <-results:
 			if result.err == nil && result.url != "" {
 				grounding[result.index].URL = result.url
+				resolvedAt := time.Now()
+				grounding[result.index].ResolvedAt = &resolvedAt
+				resolved++
+				if result.cached {
+					cacheHits++
+				}
 			} else if result.err != nil {
+				failed++
 				// Log the error but continue; non-fatal.
-				log.Printf("warning: failed to resolve origin URL for index %d: %v", result.index+1, result.err)
+				c.logger.WarnContext(ctx, "gemini: failed to resolve origin URL",
+					slog.Int("index", result.index+1),
+					slog.Any("error", result.err),
+				)
 			}
 		case <-resolveCtx.Done():
-			log.Printf("warning: URL resolution timed out, some URLs may remain unresolved")
+			c.logger.WarnContext(ctx, "gemini: URL resolution timed out, some URLs may remain unresolved")
+			failed += jobCount - i
 			return
 		}
 	}
@@ -465,13 +1033,45 @@ func (c *Client) resolveGroundingURLs(ctx context.Context, grounding []Grounding
 // urlResolveWorker processes URL resolution jobs
 func (c *Client) urlResolveWorker(ctx context.Context, jobs <-chan urlResolveJob, results chan<- urlResolveResult) {
 	for job := range jobs {
-		origin, err := resolveOriginURL(ctx, c.httpClient, job.url)
+		origin, cached, err := c.resolveURL(ctx, job.url)
 		results <- urlResolveResult{
-			index: job.index,
-			url:   origin,
-			err:   err,
+			index:  job.index,
+			url:    origin,
+			cached: cached,
+			err:    err,
+		}
+	}
+}
+
+// resolveURL resolves one redirect URL to its origin URL, decoding it locally if offline
+// decoding is enabled and using the URL resolution cache. cached reports a cache hit.
+func (c *Client) resolveURL(ctx context.Context, rawURL string) (origin string, cached bool, err error) {
+	if c.config.OfflineURLDecoding {
+		if target, ok := decodeRedirectURL(rawURL); ok {
+			return target, false, nil
+		}
+	}
+
+	origin, ok, err := c.urlCache.get(ctx, rawURL)
+	if err != nil {
+		c.logger.WarnContext(ctx, "gemini: failed to read URL resolution cache", slog.Any("error", err))
+	}
+	if ok {
+		return origin, true, nil
+	}
+
+	release, err := c.hostLimiter.acquire(ctx, rawURL)
+	if err != nil {
+		return "", false, err
+	}
+	origin, err = resolveOriginURL(ctx, c.resolverClient, c.resolverHeader, rawURL)
+	release()
+	if err == nil && origin != "" {
+		if err := c.urlCache.set(ctx, rawURL, origin); err != nil {
+			c.logger.WarnContext(ctx, "gemini: failed to write URL resolution cache", slog.Any("error", err))
 		}
 	}
+	return origin, false, err
 }
 
 // createResolveContext creates a context with appropriate timeout for URL resolution
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	search "github.com/cnosuke/go-gemini-grounded-search"
+	"github.com/cnosuke/go-gemini-grounded-search/store"
+	"github.com/urfave/cli/v3"
+)
+
+// historyPrefix is the store key prefix of history entries.
+const historyPrefix = "history/"
+
+// historyEntry is a saved search.
+type historyEntry struct {
+	ID       string           `json:"id"`
+	Time     time.Time        `json:"time"`
+	Query    string           `json:"query"`
+	Model    string           `json:"model,omitempty"`
+	Response *search.Response `json:"response"`
+}
+
+// defaultHistoryFile returns the default location of the history file.
+func defaultHistoryFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gemini-search", "history.json")
+}
+
+// openHistory opens the history store configured by the --history-file flag.
+func openHistory(cmd *cli.Command) (store.Store, error) {
+	path := cmd.String("history-file")
+	if path == "" {
+		path = defaultHistoryFile()
+	}
+	if path == "" {
+		return nil, fmt.Errorf("cannot determine the history file location; set --history-file")
+	}
+	return store.NewFile(path)
+}
+
+// saveHistory records a completed search.
+func saveHistory(ctx context.Context, s store.Store, query, model string, resp *search.Response) error {
+	now := time.Now()
+	entry := historyEntry{
+		ID:       now.UTC().Format("20060102-150405.000"),
+		Time:     now,
+		Query:    query,
+		Model:    model,
+		Response: resp,
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.Set(ctx, historyPrefix+entry.ID, b, 0)
+}
+
+// recordHistory saves a completed search to the history store configured by cmd.
+func recordHistory(ctx context.Context, cmd *cli.Command, query, model string, resp *search.Response) error {
+	s, err := openHistory(cmd)
+	if err != nil {
+		return err
+	}
+	return saveHistory(ctx, s, query, model, resp)
+}
+
+// loadHistory returns all history entries, oldest first.
+func loadHistory(ctx context.Context, s store.Store) ([]historyEntry, error) {
+	stored, err := s.List(ctx, historyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]historyEntry, 0, len(stored))
+	for _, e := range stored {
+		var h historyEntry
+		if err := json.Unmarshal(e.Value, &h); err != nil {
+			return nil, fmt.Errorf("corrupt history entry %s: %w", e.Key, err)
+		}
+		entries = append(entries, h)
+	}
+	return entries, nil
+}
+
+// loadHistoryEntry returns the history entry with the given ID.
+func loadHistoryEntry(ctx context.Context, s store.Store, id string) (*historyEntry, error) {
+	b, ok, err := s.Get(ctx, historyPrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no history entry %q", id)
+	}
+	var h historyEntry
+	if err := json.Unmarshal(b, &h); err != nil {
+		return nil, fmt.Errorf("corrupt history entry %s: %w", id, err)
+	}
+	return &h, nil
+}
+
+// printHistoryList prints one line per entry, newest first.
+func printHistoryList(entries []historyEntry) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		fmt.Printf("%s  %s  %s\n", e.ID, e.Time.Local().Format("2006-01-02 15:04"), oneLine(e.Query))
+	}
+}
+
+// oneLine collapses whitespace in s so it fits on a single line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// historyCommand is the "history" subcommand.
+func historyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "Browse and export past searches.",
+		Commands: []*cli.Command{
+			{
+				Name:  "ls",
+				Usage: "List past searches, newest first.",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:    "limit",
+						Aliases: []string{"n"},
+						Usage:   "Show only the most recent N searches.",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					s, err := openHistory(cmd)
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+					entries, err := loadHistory(ctx, s)
+					if err != nil {
+						return cli.Exit(fmt.Sprintf("Failed to read history: %v", err), 1)
+					}
+					if n := int(cmd.Int("limit")); n > 0 && n < len(entries) {
+						entries = entries[len(entries)-n:]
+					}
+					printHistoryList(entries)
+					return nil
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Show a saved answer with its sources.",
+				ArgsUsage: "ID",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "markdown",
+						Usage: "Print the answer as Markdown with footnoted sources.",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					id := cmd.Args().First()
+					if id == "" {
+						return cli.Exit("History entry ID argument is required.", 1)
+					}
+					s, err := openHistory(cmd)
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+					entry, err := loadHistoryEntry(ctx, s, id)
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+					fmt.Printf("# %s\n\n", oneLine(entry.Query))
+					if cmd.Bool("markdown") {
+						fmt.Println(entry.Response.ToMarkdown())
+					} else {
+						printPlain(entry.Response)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "search",
+				Usage:     "Search past queries and answers.",
+				ArgsUsage: "TERM",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					term := strings.ToLower(strings.Join(cmd.Args().Slice(), " "))
+					if term == "" {
+						return cli.Exit("Search term argument is required.", 1)
+					}
+					s, err := openHistory(cmd)
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+					entries, err := loadHistory(ctx, s)
+					if err != nil {
+						return cli.Exit(fmt.Sprintf("Failed to read history: %v", err), 1)
+					}
+					var matches []historyEntry
+					for _, e := range entries {
+						if strings.Contains(strings.ToLower(e.Query), term) ||
+							(e.Response != nil && strings.Contains(strings.ToLower(e.Response.GeneratedText), term)) {
+							matches = append(matches, e)
+						}
+					}
+					printHistoryList(matches)
+					return nil
+				},
+			},
+			{
+				Name:      "export",
+				Usage:     "Export saved searches (all of them if no IDs are given).",
+				ArgsUsage: "[ID...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "json",
+						Usage: "Output format (json, markdown).",
+					},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					format := strings.ToLower(cmd.String("format"))
+					if format != "json" && format != "markdown" {
+						return cli.Exit(fmt.Sprintf("invalid format %q: must be json or markdown", format), 1)
+					}
+					s, err := openHistory(cmd)
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+
+					var entries []historyEntry
+					if ids := cmd.Args().Slice(); len(ids) > 0 {
+						for _, id := range ids {
+							entry, err := loadHistoryEntry(ctx, s, id)
+							if err != nil {
+								return cli.Exit(err.Error(), 1)
+							}
+							entries = append(entries, *entry)
+						}
+					} else if entries, err = loadHistory(ctx, s); err != nil {
+						return cli.Exit(fmt.Sprintf("Failed to read history: %v", err), 1)
+					}
+
+					if format == "json" {
+						enc := json.NewEncoder(os.Stdout)
+						enc.SetIndent("", "  ")
+						return enc.Encode(entries)
+					}
+					for i, e := range entries {
+						if i > 0 {
+							fmt.Println("\n---")
+						}
+						fmt.Printf("## %s\n\n_%s_\n\n%s\n", oneLine(e.Query), e.Time.Local().Format("2006-01-02 15:04"), e.Response.ToMarkdown())
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
@@ -29,6 +31,32 @@ func parseThinkingLevel(s string) (search.ThinkingLevel, error) {
 	}
 }
 
+// readContext returns the content of the file at path, or of stdin if path is "-".
+// It returns an empty string if path is empty.
+func readContext(path string) (string, error) {
+	switch path {
+	case "":
+		return "", nil
+	case "-":
+		b, err := io.ReadAll(os.Stdin)
+		return string(b), err
+	default:
+		b, err := os.ReadFile(path)
+		return string(b), err
+	}
+}
+
+// printPlain prints the generated text followed by a plain list of sources.
+func printPlain(resp *search.Response) {
+	fmt.Println(resp.GeneratedText)
+	if len(resp.GroundingAttributions) > 0 {
+		fmt.Println("\n---\nSources:")
+		for _, attr := range resp.GroundingAttributions {
+			fmt.Printf("- %s (%s)\n", attr.Title, attr.URL)
+		}
+	}
+}
+
 func main() {
 	cmd := &cli.Command{
 		Name:  "gemini-search",
@@ -49,12 +77,33 @@ func main() {
 				Aliases: []string{"t"},
 				Usage:   "Thinking level for the model (minimal, low, medium, high). For Gemini 3/3.1/3.5 series models (e.g., gemini-3.5-flash, gemini-3.1-pro-preview, gemini-3-flash-preview).",
 			},
+			&cli.StringFlag{
+				Name:    "context",
+				Aliases: []string{"c"},
+				Usage:   "File whose content is attached as context for the question. Use \"-\" to read from stdin.",
+			},
+			&cli.BoolFlag{
+				Name:  "markdown",
+				Usage: "Print the answer as Markdown with footnoted sources.",
+			},
+			&cli.StringFlag{
+				Name:    "history-file",
+				Usage:   "File where searches are saved for the history subcommand (default: gemini-search/history.json in the user config directory).",
+				Sources: cli.EnvVars("GEMINI_SEARCH_HISTORY_FILE"),
+			},
+			&cli.BoolFlag{
+				Name:  "no-history",
+				Usage: "Do not save this search to the history.",
+			},
 			&cli.BoolFlag{
 				Name:    "verbose",
 				Aliases: []string{"v"},
 				Usage:   "Enable verbose output for debugging.",
 			},
 		},
+		Commands: []*cli.Command{
+			historyCommand(),
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			apiKey := cmd.String("api-key")
 			if apiKey == "" {
@@ -77,8 +126,19 @@ func main() {
 				return cli.Exit("Search query argument is required.", 1)
 			}
 
+			contextMaterial, err := readContext(cmd.String("context"))
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Failed to read context: %v", err), 1)
+			}
+
+			logLevel := slog.LevelWarn
+			if cmd.Bool("verbose") {
+				logLevel = slog.LevelDebug
+			}
+			logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+
 			var clientOpts []search.ClientOption
-			clientOpts = append(clientOpts, search.WithNoRedirection())
+			clientOpts = append(clientOpts, search.WithNoRedirection(), search.WithLogger(logger))
 			if model != "" {
 				clientOpts = append(clientOpts, search.WithModelName(model))
 			}
@@ -103,26 +163,36 @@ func main() {
 				log.Printf("API Key: %s****%s", apiKey[:4], apiKey[len(apiKey)-4:])
 				log.Printf("Using model: %s", model)
 				log.Printf("Search query: %s", query)
+				if contextMaterial != "" {
+					log.Printf("Context: %d bytes", len(contextMaterial))
+				}
 				if tl := cmd.String("thinking-level"); tl != "" {
 					log.Printf("Thinking level: %s", strings.ToUpper(tl))
 				}
 			}
 
-			resp, err := client.GenerateGroundedContent(ctx, query)
+			resp, err := client.GenerateGroundedContentWithParams(ctx, &search.GenerationParams{
+				Prompt:          query,
+				ContextMaterial: contextMaterial,
+			})
 			if err != nil {
 				return cli.Exit(fmt.Sprintf("Search failed: %v", err), 1)
 			}
 
 			finishNow := time.Now()
 
-			fmt.Println(resp.GeneratedText)
-			if len(resp.GroundingAttributions) > 0 {
-				fmt.Println("\n---\nSources:")
-				for _, attr := range resp.GroundingAttributions {
-					fmt.Printf("- %s (%s)\n", attr.Title, attr.URL)
+			if !cmd.Bool("no-history") {
+				if err := recordHistory(ctx, cmd, query, model, resp); err != nil {
+					logger.Warn("failed to save search history", slog.Any("error", err))
 				}
 			}
 
+			if cmd.Bool("markdown") {
+				fmt.Println(resp.ToMarkdown())
+			} else {
+				printPlain(resp)
+			}
+
 			if cmd.Bool("verbose") {
 				log.Printf("\n=========\nSearch completed in %s\n", finishNow.Sub(startNow))
 			}
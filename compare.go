@@ -0,0 +1,135 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// ComparisonCell is the grounded answer for one entity and one aspect of a comparison.
+type ComparisonCell struct {
+	// Entity is the entity this cell describes.
+	Entity string `json:"entity"`
+
+	// Aspect is the aspect this cell describes.
+	Aspect string `json:"aspect"`
+
+	// Text is the grounded answer for the entity and aspect.
+	Text string `json:"text,omitempty"`
+
+	// GroundingAttributions lists the sources cited for this cell.
+	GroundingAttributions []GroundingAttribution `json:"grounding_attributions,omitempty"`
+
+	// Err is set if the sub-query for this cell failed.
+	Err error `json:"-"`
+}
+
+// Comparison is a structured comparison of two entities across a list of aspects.
+type Comparison struct {
+	// Entities holds the two compared entities, in the order given.
+	Entities [2]string `json:"entities"`
+
+	// Aspects holds the compared aspects, in the order given.
+	Aspects []string `json:"aspects"`
+
+	// Cells is the comparison matrix, indexed as Cells[aspectIndex][entityIndex].
+	Cells [][2]ComparisonCell `json:"cells"`
+}
+
+// Cell returns the cell for the given aspect and entity, or nil if either is not part of the comparison.
+func (c *Comparison) Cell(aspect, entity string) *ComparisonCell {
+	for i, a := range c.Aspects {
+		if a != aspect {
+			continue
+		}
+		for j, e := range c.Entities {
+			if e == entity {
+				return &c.Cells[i][j]
+			}
+		}
+	}
+	return nil
+}
+
+// CompareEntities compares entities a and b across aspects (e.g., "price", "battery life").
+// It runs one targeted grounded sub-query per entity and aspect, concurrently, and returns
+// a comparison matrix whose cells carry their own citations.
+// A failed sub-query is recorded in the cell's Err; an error is returned only if every
+// sub-query fails or the input is invalid.
+func (c *Client) CompareEntities(ctx context.Context, a, b string, aspects []string) (*Comparison, error) {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == "" || b == "" {
+		return nil, ierrors.Wrap(ErrInvalidParameter, "entities to compare cannot be empty")
+	}
+	if len(aspects) == 0 {
+		return nil, ierrors.Wrap(ErrInvalidParameter, "at least one aspect is required for a comparison")
+	}
+	for _, aspect := range aspects {
+		if strings.TrimSpace(aspect) == "" {
+			return nil, ierrors.Wrap(ErrInvalidParameter, "comparison aspects cannot be empty")
+		}
+	}
+
+	cmp := &Comparison{
+		Entities: [2]string{a, b},
+		Aspects:  append([]string(nil), aspects...),
+		Cells:    make([][2]ComparisonCell, len(aspects)),
+	}
+
+	sem := make(chan struct{}, DefaultCompareConcurrency)
+	var wg sync.WaitGroup
+	for i, aspect := range aspects {
+		for j, entity := range cmp.Entities {
+			other := cmp.Entities[1-j]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cell := ComparisonCell{Entity: entity, Aspect: aspect}
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					cell.Err = ctx.Err()
+					cmp.Cells[i][j] = cell
+					return
+				}
+
+				resp, err := c.GenerateGroundedContentWithParams(ctx, &GenerationParams{
+					Prompt: buildComparisonPrompt(entity, other, aspect),
+				})
+				if err != nil {
+					cell.Err = err
+				} else {
+					cell.Text = resp.GeneratedText
+					cell.GroundingAttributions = resp.GroundingAttributions
+				}
+				cmp.Cells[i][j] = cell
+			}()
+		}
+	}
+	wg.Wait()
+
+	var firstErr error
+	for i := range cmp.Cells {
+		for j := range cmp.Cells[i] {
+			if cmp.Cells[i][j].Err == nil {
+				return cmp, nil
+			}
+			if firstErr == nil {
+				firstErr = cmp.Cells[i][j].Err
+			}
+		}
+	}
+	return nil, ierrors.Wrap(firstErr, "all comparison sub-queries failed")
+}
+
+// buildComparisonPrompt builds the sub-query for one cell of a comparison.
+func buildComparisonPrompt(entity, other, aspect string) string {
+	return fmt.Sprintf(`Describe %q with respect to the following aspect: %s.
+This answer is one cell of a side-by-side comparison with %q, so focus only on %q and only on this aspect.
+Be concise and factual, include concrete figures and dates where available, and rely on current, reliable sources.`,
+		entity, aspect, other, entity)
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// requestCompressionMinSize is the body size from which requests are compressed. Smaller bodies
+// gain too little to be worth the CPU time.
+const requestCompressionMinSize = 1024
+
+// compressRequests returns a copy of client, or of a default client if client is nil, that
+// gzip-compresses JSON request bodies of at least requestCompressionMinSize bytes.
+func compressRequests(client *http.Client) *http.Client {
+	compressing := &http.Client{}
+	if client != nil {
+		*compressing = *client
+	}
+	base := compressing.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	compressing.Transport = &compressingTransport{base: base}
+	return compressing
+}
+
+// compressingTransport gzip-compresses the bodies of JSON requests (see WithRequestCompression).
+// Responses need no handling here: http.Transport asks for gzip-compressed responses and
+// decompresses them transparently.
+type compressingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *compressingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Header.Get("Content-Encoding") != "" || !isJSONContentType(req.Header.Get("Content-Type")) {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, ierrors.Wrap(err, "failed to read request body for compression")
+	}
+	if len(body) < requestCompressionMinSize {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		return t.base.RoundTrip(req)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, ierrors.Wrap(err, "failed to compress request body")
+	}
+	if err := zw.Close(); err != nil {
+		return nil, ierrors.Wrap(err, "failed to compress request body")
+	}
+
+	// RoundTrippers must not modify the caller's request, so send a copy.
+	compressed := req.Clone(req.Context())
+	compressed.Header.Set("Content-Encoding", "gzip")
+	compressed.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+	compressed.ContentLength = int64(buf.Len())
+	compressed.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	}
+	return t.base.RoundTrip(compressed)
+}
+
+// isJSONContentType reports whether contentType is a JSON media type.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
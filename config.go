@@ -1,16 +1,58 @@
 package search
 
 import (
+	"context"
+	"crypto/tls"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"time"
+
+	"cloud.google.com/go/auth"
+	"cloud.google.com/go/auth/credentials"
+	"cloud.google.com/go/auth/httptransport"
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"github.com/cnosuke/go-gemini-grounded-search/store"
+	"google.golang.org/genai"
 )
 
+// cloudPlatformScope is the OAuth scope requested for Application Default Credentials.
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
 // ClientConfig holds the configuration for the Gemini API client.
 type ClientConfig struct {
 	// APIKey is the Google AI API key for authenticating requests.
-	// This field is mandatory.
+	// It is mandatory unless Project is set, in which case it must be empty.
 	APIKey string
 
+	// APIKeys are additional API keys that generation requests rotate over together with APIKey.
+	// Other calls (files, caches, and model lookups) always use APIKey. See WithAPIKeys.
+	APIKeys []string
+
+	// Backend selects the API service (Gemini API or Vertex AI).
+	// Defaults to BackendGeminiAPI.
+	Backend Backend
+
+	// Project is the Google Cloud project of the Vertex AI backend when authenticating with
+	// OAuth credentials instead of an API key. See WithVertexAIProject.
+	Project string
+
+	// Location is the Vertex AI location (e.g., "us-central1") used with Project.
+	// If empty, "global" is used.
+	Location string
+
+	// RecordingDir, if set, records all API and URL-resolution traffic to this directory.
+	// See WithRecording.
+	RecordingDir string
+
+	// ReplayDir, if set, answers all API and URL-resolution requests from recordings in this
+	// directory instead of the network. See WithReplay.
+	ReplayDir string
+
+	// Credentials authenticate requests to the Vertex AI backend when Project is set.
+	// If nil, Application Default Credentials are used. See WithCredentials.
+	Credentials *auth.Credentials
+
 	// ModelName is the default Gemini model to be used for requests (e.g., "gemini-3.5-flash").
 	// Can be overridden per request via GenerationParams.
 	ModelName string
@@ -33,6 +75,18 @@ type ClientConfig struct {
 	// If nil, the underlying SDK/API default will be used.
 	DefaultTopP *float32
 
+	// DefaultSeed is the default random seed used for decoding, for reproducible outputs.
+	// If nil, the underlying SDK/API default (a random seed) will be used.
+	DefaultSeed *int32
+
+	// DefaultPresencePenalty is the default penalty for tokens that already appear in the output.
+	// If nil, the underlying SDK/API default will be used.
+	DefaultPresencePenalty *float32
+
+	// DefaultFrequencyPenalty is the default penalty for tokens proportional to their frequency
+	// in the output. If nil, the underlying SDK/API default will be used.
+	DefaultFrequencyPenalty *float32
+
 	// DefaultSafetySettings is a list of default safety settings to apply to requests.
 	// These can be overridden per request via GenerationParams.
 	// If nil or empty, the underlying SDK/API defaults will apply.
@@ -44,36 +98,400 @@ type ClientConfig struct {
 	// For Gemini 2.5 series models, use ThinkingBudget (set to 0 to disable thinking).
 	DefaultThinkingConfig *ThinkingConfig
 
+	// ResponseMIMEType is the default MIME type of the generated text (e.g., "application/json", "text/plain").
+	// Can be overridden per request via GenerationParams.
+	// If empty, the underlying SDK/API default will be used.
+	ResponseMIMEType string
+
+	// Tools lists additional SDK tools (e.g., code execution, Google Maps) sent with every request,
+	// composed with the Google Search Tool and any other tools enabled by the client.
+	Tools []*genai.Tool
+
+	// Functions lists user-defined functions the model may call alongside the Google Search Tool.
+	// Additional functions can be supplied per request via GenerationParams.
+	Functions []Function
+
+	// SearchScopes lists the named search scopes requests can select via GenerationParams.Scope.
+	SearchScopes []SearchScope
+
+	// CachedContent is the name of cached content (see Client.CreateCachedContent) used by every
+	// request. Can be overridden per request via GenerationParams.CachedContent.
+	CachedContent string
+
+	// MaxFunctionCallRounds limits the number of function-call round-trips within a single request.
+	MaxFunctionCallRounds int
+
 	// HTTPClient allows providing a custom *http.Client for making API requests.
 	// If nil, the underlying genai SDK will use its default HTTP client.
 	HTTPClient *http.Client
 
+	// ProxyURL, if set, routes both API requests and URL-resolution requests through
+	// the given HTTP proxy.
+	ProxyURL *url.URL
+
+	// MaxIdleConns, IdleConnTimeout, and MaxConnsPerHost tune connection pooling of the
+	// transport shared by API requests and URL-resolution requests (see WithTransportTuning).
+	// Zero values leave the transport's settings unchanged.
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+	MaxConnsPerHost int
+
+	// RequestCompression gzip-compresses the JSON bodies of API requests (see WithRequestCompression).
+	RequestCompression bool
+
+	// ResolverOptions customizes the HEAD requests used to resolve grounding redirect URLs,
+	// independently of the API requests.
+	ResolverOptions ResolverOptions
+
+	// HTTPOptions customizes how the underlying SDK talks to the API, e.g., to route
+	// traffic through a gateway with a different host and custom authentication headers.
+	HTTPOptions HTTPOptions
+
+	// UserAgentSuffix is appended to the library's User-Agent (e.g., "my-app/2.1")
+	// so applications can identify themselves in upstream logs.
+	UserAgentSuffix string
+
+	// Logger receives the client's diagnostic output (request start/finish, retries,
+	// URL-resolution failures). If nil, nothing is logged.
+	Logger *slog.Logger
+
+	// RateLimit, if positive, limits API calls made by the client to this many per second
+	// using a token bucket with capacity RateLimitBurst. Calls wait for a token.
+	RateLimit      float64
+	RateLimitBurst int
+
+	// CircuitBreakerThreshold, if positive, opens the circuit breaker after this many
+	// consecutive server errors. While open, requests fail fast with ErrCircuitOpen until
+	// CircuitBreakerCooldown has passed.
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	// Interceptors wrap every generation call, in order; the first is the outermost.
+	Interceptors []Interceptor
+
+	// PromptRedactor, if set, is applied to prompts before they are logged or passed to hooks.
+	PromptRedactor func(string) string
+
+	// OnRequest, OnResponse, and OnError are called, in order, when a generation call starts,
+	// succeeds, or fails, respectively.
+	OnRequest  []RequestHook
+	OnResponse []ResponseHook
+	OnError    []ErrorHook
+
+	// MetricsRecorder receives per-request and URL-resolution metrics. If nil, metrics are discarded.
+	MetricsRecorder MetricsRecorder
+
 	// RequestTimeout is the default timeout duration for API requests made by the client.
 	// If zero, no specific timeout is set at this library's client level, relying on
 	// context deadlines or underlying SDK/HTTP client timeouts.
 	RequestTimeout time.Duration
 
+	// SoftTimeout, if positive, streams responses and stops generation once it has elapsed,
+	// returning the text and grounding metadata received so far as a partial Response
+	// (Response.Partial) instead of a deadline error.
+	SoftTimeout time.Duration
+
+	// EnterpriseWebSearch, if true, uses the Vertex AI enterprise web search tool
+	// (compliance-filtered web grounding) instead of the Google Search Tool.
+	// Requires BackendVertexAI.
+	EnterpriseWebSearch bool
+
 	// DisableGroundingToolGlobally, if true, makes the client not automatically enable
 	// the Google Search Tool, even if the method implies its use.
 	// Grounding can then be explicitly enabled via GenerationParams or specific methods.
 	// Given the library name, this would typically be false.
 	DisableGoogleSearchToolGlobally bool
 
+	// EnableURLContext, if true, enables the URL Context tool so the model can read pages
+	// whose URLs appear in the prompt. It can be combined with the Google Search Tool or,
+	// together with DisableGoogleSearchToolGlobally, used instead of it.
+	EnableURLContext bool
+
 	// NoRedirection, if true, instructs the client to resolve the original URL
 	// from any redirected URL returned by the grounding service.
 	NoRedirection bool
+
+	// InjectionGuard, if true, wraps prompts in instructions to treat search results as data and
+	// flags instruction-like content in responses (see WithInjectionGuard).
+	InjectionGuard bool
+
+	// EnrichSources, if true, fetches the page of every source cited by a response and attaches
+	// its snippet, word count, publication date, and site metadata to the attribution.
+	EnrichSources bool
+
+	// ValidateSources, if true, checks that every source cited by a response is reachable and
+	// records its HTTP status. It is implied by EnrichSources.
+	ValidateSources bool
+
+	// ArchiveFallback, if true, looks up an Internet Archive snapshot of every source found by
+	// validation or enrichment to no longer exist (HTTP 404 or 410) and sets GroundingAttribution.ArchiveURL.
+	ArchiveFallback bool
+
+	// OfflineURLDecoding, if true, extracts the target of redirect URLs that encode it locally
+	// instead of resolving them with a HEAD request. Other URLs are still resolved over the network.
+	OfflineURLDecoding bool
+
+	// QueryModeration, if true, classifies every query with ModerationModelName before the
+	// grounded request and fails fast with a *QueryRejectedError for disallowed queries.
+	QueryModeration bool
+
+	// ModerationModelName is the model used for query moderation.
+	ModerationModelName string
+
+	// ModelRouter, if set, picks the model of requests that do not name one by classifying the
+	// query's complexity. See WithModelRouter.
+	ModelRouter *ModelRoutingPolicy
+
+	// UserLocation, if set, tells the model where the user is, so that searches prefer
+	// locale-relevant sources and units. Can be overridden per request via GenerationParams.UserLocation.
+	UserLocation *UserLocation
+
+	// MinGroundingConfidence, if positive, drops attributions whose segments are all scored below
+	// this confidence. Can be overridden per request via GenerationParams.MinConfidence.
+	MinGroundingConfidence float32
+
+	// AllowedDomains, if not empty, restricts the attributions of every response to these domains
+	// and their subdomains (e.g., "gov", "example.edu"). Attributions whose domain cannot be
+	// determined are removed as well. Can be overridden per request via GenerationParams.AllowedDomains.
+	AllowedDomains []string
+
+	// BlockedDomains removes the attributions from these domains and their subdomains
+	// (e.g., "pinterest.com") from every response. Can be overridden per request via
+	// GenerationParams.BlockedDomains.
+	BlockedDomains []string
+
+	// MinDistinctDomains, if positive, is the minimum number of distinct source domains a
+	// response should cite. If a response cites fewer, the request is retried once with an
+	// instruction to consult additional independent sources.
+	MinDistinctDomains int
+
+	// URLResolutionConcurrency is the number of grounding URLs resolved concurrently for a response.
+	URLResolutionConcurrency int
+
+	// URLResolutionCache, if set, caches resolved grounding redirect URLs so that sources cited
+	// again are not resolved again. Entries expire after URLResolutionCacheTTL.
+	URLResolutionCache    store.Store
+	URLResolutionCacheTTL time.Duration
+
+	// MaxConcurrentRequestsPerHost limits how many requests the client sends to the same
+	// host at once while resolving or fetching source URLs. Zero disables the limit.
+	MaxConcurrentRequestsPerHost int
+}
+
+// HTTPOptions customizes the HTTP requests sent to the Gemini API.
+type HTTPOptions struct {
+	// BaseURL overrides the API endpoint (e.g., "https://gemini-gateway.internal.example.com/").
+	// If empty, the SDK default for the selected backend is used.
+	BaseURL string
+
+	// APIVersion overrides the API version path segment (e.g., "v1", "v1beta").
+	// If empty, the SDK default for the selected backend is used.
+	APIVersion string
+
+	// Headers are added to every API request.
+	Headers http.Header
+
+	// TLSConfig, if set, is used for API requests (e.g., to trust the internal CA of a gateway or
+	// to present a client certificate). It does not affect URL-resolution requests, which use
+	// ResolverOptions.TLSConfig.
+	TLSConfig *tls.Config
+}
+
+// toSDK converts the HTTPOptions to the SDK's genai.HTTPOptions.
+func (o HTTPOptions) toSDK() genai.HTTPOptions {
+	return genai.HTTPOptions{
+		BaseURL:    o.BaseURL,
+		APIVersion: o.APIVersion,
+		Headers:    o.Headers.Clone(),
+	}
+}
+
+// ResolverOptions customizes the HTTP requests used to resolve grounding redirect URLs.
+// Many publishers reject requests without a browser-like User-Agent, and enterprise networks
+// may require a custom certificate pool.
+type ResolverOptions struct {
+	// UserAgent overrides the User-Agent header. If empty, the library's User-Agent is used.
+	UserAgent string
+
+	// AcceptLanguage sets the Accept-Language header (e.g., "en-US,en;q=0.9").
+	AcceptLanguage string
+
+	// Headers are added to every resolution request.
+	Headers http.Header
+
+	// TLSConfig, if set, is used for resolution requests (e.g., to trust a corporate CA bundle).
+	// The proxy configuration of the API HTTP client still applies.
+	TLSConfig *tls.Config
+}
+
+// header returns the headers to send with each resolution request.
+func (o ResolverOptions) header(defaultUserAgent string) http.Header {
+	h := o.Headers.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	switch {
+	case o.UserAgent != "":
+		h.Set("User-Agent", o.UserAgent)
+	case h.Get("User-Agent") == "":
+		h.Set("User-Agent", defaultUserAgent)
+	}
+	if o.AcceptLanguage != "" {
+		h.Set("Accept-Language", o.AcceptLanguage)
+	}
+	return h
+}
+
+// buildResolverClient returns the HTTP client used for URL resolution. Without a custom TLS
+// configuration it is apiClient (which may be nil to use the defaults); otherwise it is a copy
+// of apiClient whose transport uses the configured TLS settings.
+func (c *ClientConfig) buildResolverClient(apiClient *http.Client) (*http.Client, error) {
+	if c.ResolverOptions.TLSConfig == nil {
+		return apiClient, nil
+	}
+	return withTLSConfig(apiClient, c.ResolverOptions.TLSConfig, "resolver")
+}
+
+// buildAPIClient returns the HTTP client used for API requests. Without a custom TLS
+// configuration it is httpClient (which may be nil to use the defaults); otherwise it is a copy
+// of httpClient whose transport uses HTTPOptions.TLSConfig.
+func (c *ClientConfig) buildAPIClient(httpClient *http.Client) (*http.Client, error) {
+	if c.HTTPOptions.TLSConfig == nil {
+		return httpClient, nil
+	}
+	return withTLSConfig(httpClient, c.HTTPOptions.TLSConfig, "API")
+}
+
+// withTLSConfig returns a copy of client (or of a default client if nil) whose transport uses
+// tlsConfig. kind names the requests the client is for in errors.
+func withTLSConfig(client *http.Client, tlsConfig *tls.Config, kind string) (*http.Client, error) {
+	var base *http.Transport
+	out := &http.Client{}
+	if client != nil {
+		*out = *client
+		switch t := client.Transport.(type) {
+		case nil:
+		case *http.Transport:
+			base = t
+		default:
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "cannot apply %s TLS config to custom HTTP transport of type %T", kind, t)
+		}
+	}
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+
+	transport := base.Clone()
+	transport.TLSClientConfig = tlsConfig.Clone()
+	out.Transport = transport
+	return out, nil
+}
+
+// buildUserAgent returns the User-Agent sent with API and URL-resolution requests.
+func (c *ClientConfig) buildUserAgent() string {
+	ua := LibraryName + "/" + LibraryVersion
+	if c.UserAgentSuffix != "" {
+		ua += " " + c.UserAgentSuffix
+	}
+	return ua
+}
+
+// buildHTTPClient returns the HTTP client to use for API and URL-resolution requests,
+// applying the configured proxy and transport tuning. It returns nil if none of them nor a
+// custom client is set, leaving the SDK and the resolver to use their defaults.
+func (c *ClientConfig) buildHTTPClient() (*http.Client, error) {
+	if c.ProxyURL == nil && !c.hasTransportTuning() {
+		return c.HTTPClient, nil
+	}
+
+	var base *http.Transport
+	client := &http.Client{}
+	if c.HTTPClient != nil {
+		*client = *c.HTTPClient
+		switch t := c.HTTPClient.Transport.(type) {
+		case nil:
+		case *http.Transport:
+			base = t
+		default:
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "cannot apply proxy or transport tuning to custom HTTP transport of type %T", t)
+		}
+	}
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+
+	transport := base.Clone()
+	if c.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(c.ProxyURL)
+	}
+	if c.MaxIdleConns > 0 {
+		transport.MaxIdleConns = c.MaxIdleConns
+		// The API is a single host, so allow it to keep as many idle connections as the pool.
+		transport.MaxIdleConnsPerHost = c.MaxIdleConns
+	}
+	if c.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = c.MaxConnsPerHost
+	}
+	client.Transport = transport
+	return client, nil
+}
+
+// hasTransportTuning reports whether any connection pooling setting is configured.
+func (c *ClientConfig) hasTransportTuning() bool {
+	return c.MaxIdleConns > 0 || c.IdleConnTimeout > 0 || c.MaxConnsPerHost > 0
+}
+
+// buildAuthenticatedClient returns a copy of base that authenticates API requests with the
+// configured credentials, or with Application Default Credentials if none are set.
+// The SDK only adds credentials to the HTTP clients it creates itself, so this is needed when a
+// custom HTTP client or proxy is configured. The returned client must not be used for requests
+// to other hosts, such as URL resolution.
+func (c *ClientConfig) buildAuthenticatedClient(ctx context.Context, base *http.Client) (*http.Client, error) {
+	creds := c.Credentials
+	if creds == nil {
+		var err error
+		if creds, err = credentials.DetectDefault(&credentials.DetectOptions{Scopes: []string{cloudPlatformScope}}); err != nil {
+			return nil, ierrors.Wrap(err, "failed to find default credentials")
+		}
+	}
+	header := make(http.Header)
+	quotaProject, err := creds.QuotaProjectID(ctx)
+	if err != nil {
+		return nil, ierrors.Wrap(err, "failed to get quota project ID")
+	}
+	if quotaProject != "" {
+		header.Set("X-Goog-User-Project", quotaProject)
+	}
+
+	transport := base.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	authed, err := httptransport.NewClient(&httptransport.Options{
+		Credentials:      creds,
+		Headers:          header,
+		BaseRoundTripper: transport,
+	})
+	if err != nil {
+		return nil, ierrors.Wrap(err, "failed to create authenticated HTTP client")
+	}
+	client := *base
+	client.Transport = authed.Transport
+	return &client, nil
 }
 
 // newDefaultClientConfig creates a ClientConfig with sensible default values.
 // These defaults will be defined in constants.go.
+// An empty apiKey is rejected by validate unless an option sets up OAuth authentication.
 func newDefaultClientConfig(apiKey string) (*ClientConfig, error) {
-	if apiKey == "" {
-		return nil, ErrMissingAPIKey
-	}
 	defaultTemp := DefaultTemperature
 
 	return &ClientConfig{
 		APIKey:             apiKey,
+		Backend:            BackendGeminiAPI,
 		ModelName:          DefaultModelName,
 		DefaultTemperature: &defaultTemp,
 		// DefaultMaxOutputTokens, DefaultTopK, DefaultTopP can be left nil to use SDK/API defaults
@@ -81,17 +499,34 @@ func newDefaultClientConfig(apiKey string) (*ClientConfig, error) {
 		DefaultSafetySettings:           nil,   // Or a predefined safe default set from constants.go
 		DisableGoogleSearchToolGlobally: false, // Enable grounding by default for this library
 		RequestTimeout:                  DefaultRequestTimeout,
+		MaxFunctionCallRounds:           DefaultMaxFunctionCallRounds,
 		NoRedirection:                   false, // Default to following redirects
+		MaxConcurrentRequestsPerHost:    DefaultMaxConcurrentRequestsPerHost,
+		URLResolutionConcurrency:        DefaultURLResolutionConcurrency,
+		ModerationModelName:             DefaultModerationModelName,
 	}, nil
 }
 
 // validate checks if the essential parts of the ClientConfig are valid.
-// Currently, it only checks for the APIKey.
+// It checks for the APIKey and for options that require a specific backend.
 func (c *ClientConfig) validate() error {
-	if c.APIKey == "" {
+	if c.Project != "" {
+		if c.APIKey != "" || len(c.APIKeys) > 0 {
+			return ierrors.Wrap(ErrInvalidParameter, "an API key cannot be used with a Google Cloud project; pass an empty API key to NewClient")
+		}
+	} else if c.APIKey == "" {
 		// This error (e.g., ErrMissingAPIKey) will be defined in errors.go
 		return ErrMissingAPIKey
 	}
+	if c.RecordingDir != "" && c.ReplayDir != "" {
+		return ierrors.Wrap(ErrInvalidParameter, "recording and replay cannot both be enabled")
+	}
+	if c.Credentials != nil && c.Project == "" {
+		return ierrors.Wrap(ErrInvalidParameter, "credentials require a Google Cloud project (see WithVertexAIProject)")
+	}
+	if c.EnterpriseWebSearch && c.Backend != BackendVertexAI {
+		return ierrors.Wrap(ErrUnsupportedFunctionality, "enterprise web search requires the Vertex AI backend")
+	}
 	// Add other validations as necessary, e.g., for ModelName format, etc.
 	return nil
 }
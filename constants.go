@@ -20,12 +20,45 @@ const (
 	// For higher reasoning quality, consider using "gemini-3.1-pro-preview" via WithModelName.
 	DefaultModelName = "gemini-3.5-flash"
 
+	// DefaultModerationModelName is the model used for query moderation.
+	// A small, inexpensive model is sufficient for classification.
+	DefaultModerationModelName = "gemini-2.5-flash-lite"
+
+	// DefaultComplexModelName is the model WithModelRouter routes complex queries to by default.
+	DefaultComplexModelName = "gemini-3.1-pro-preview"
+
 	// DefaultTemperature for grounded search tasks.
 	// 0.0f is generally recommended for factuality and to minimize hallucinations.
 	DefaultTemperature float32 = 0.0
 
 	// DefaultRequestTimeout is the default duration for API requests.
 	DefaultRequestTimeout = 60 * time.Second
+
+	// DefaultMaxFunctionCallRounds is the default number of function-call round-trips
+	// allowed within a single generation request.
+	DefaultMaxFunctionCallRounds = 5
+
+	// DefaultMaxConcurrentRequestsPerHost is the default number of concurrent requests
+	// the client sends to a single host while resolving or fetching source URLs.
+	DefaultMaxConcurrentRequestsPerHost = 2
+
+	// DefaultURLResolutionConcurrency is the default number of grounding URLs the client
+	// resolves concurrently for a response.
+	DefaultURLResolutionConcurrency = 8
+
+	// DefaultURLResolutionCacheTTL is how long resolved redirect URLs are cached by default
+	// when the URL resolution cache is enabled.
+	DefaultURLResolutionCacheTTL = 24 * time.Hour
+
+	// MaxContextURLs is the maximum number of URLs the URL Context tool accepts per request.
+	MaxContextURLs = 20
+
+	// DefaultCompareConcurrency is the number of sub-queries CompareEntities runs concurrently.
+	DefaultCompareConcurrency = 4
+
+	// DefaultSummarizeChunkSize is the default maximum chunk length, in characters,
+	// used by GroundedSummarize.
+	DefaultSummarizeChunkSize = 8000
 )
 
 // Note: Constants for HarmCategory and HarmBlockThreshold are defined in types.go
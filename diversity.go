@@ -0,0 +1,45 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// enforceDomainDiversity checks that resp cites at least MinDistinctDomains distinct domains.
+// If it does not, the request is retried once with guidance to consult additional independent
+// sources, and the better of the two responses is returned. The outcome is recorded in
+// Response.DomainDiversityMet and the retry is appended to attempts.
+// A failed retry is not an error; the original response is kept.
+func (c *Client) enforceDomainDiversity(ctx context.Context, params *GenerationParams, resp *Response, attempts *[]Attempt) *Response {
+	minDomains := c.config.MinDistinctDomains
+	if len(resp.Domains()) >= minDomains {
+		resp.DomainDiversityMet = boolPtr(true)
+		return resp
+	}
+
+	retryParams := *params
+	guidance := fmt.Sprintf("Consult and cite at least %d independent sources from different websites (distinct domains). "+
+		"Do not rely on a single publisher.", minDomains)
+	if strings.TrimSpace(retryParams.SearchGuidance) != "" {
+		guidance = retryParams.SearchGuidance + "\n" + guidance
+	}
+	retryParams.SearchGuidance = guidance
+
+	c.logger.InfoContext(ctx, "gemini: too few distinct source domains, retrying",
+		slog.Int("domains", len(resp.Domains())),
+		slog.Int("min_domains", minDomains),
+	)
+	retried, err := c.generateAttempt(ctx, &retryParams, AttemptReasonDomainDiversity, attempts)
+	if err == nil && len(retried.Domains()) > len(resp.Domains()) {
+		resp = retried
+	}
+	resp.DomainDiversityMet = boolPtr(len(resp.Domains()) >= minDomains)
+	return resp
+}
+
+// boolPtr returns a pointer to b.
+func boolPtr(b bool) *bool {
+	return &b
+}
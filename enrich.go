@@ -0,0 +1,196 @@
+package search
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// maxSourceBytes is the maximum number of bytes read from a source page.
+	maxSourceBytes = 2 << 20
+
+	// snippetLength is the maximum length, in characters, of GroundingAttribution.Snippet.
+	snippetLength = 300
+
+	// sourceFetchTimeout is the per-request timeout for fetching a source page.
+	sourceFetchTimeout = 10 * time.Second
+)
+
+// sourcePage is a fetched source page.
+type sourcePage struct {
+	// StatusCode is the HTTP status code of the final response.
+	StatusCode int
+
+	// URL is the URL of the final response, after redirects.
+	URL *url.URL
+
+	// Header holds the headers of the final response.
+	Header http.Header
+
+	// Doc is the parsed document, or nil if the page is not HTML.
+	Doc *html.Node
+
+	// Text is the body of a plain-text page.
+	Text string
+}
+
+// fetchSource fetches the page at urlStr, following redirects.
+// Only HTML and plain-text bodies are read, up to maxSourceBytes.
+func fetchSource(ctx context.Context, customClient *http.Client, header http.Header, urlStr string) (*sourcePage, error) {
+	client := &http.Client{Timeout: sourceFetchTimeout}
+	if customClient != nil {
+		client.Transport = customClient.Transport
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
+	if err != nil {
+		return nil, ierrors.Wrapf(err, "failed to create request for %s", urlStr)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, ierrors.Wrapf(err, "failed to send GET request to %s", urlStr)
+	}
+	defer resp.Body.Close()
+
+	page := &sourcePage{StatusCode: resp.StatusCode, URL: resp.Request.URL, Header: resp.Header}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return page, nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxSourceBytes), contentType)
+	if err != nil {
+		return nil, ierrors.Wrapf(err, "failed to decode body of %s", urlStr)
+	}
+	switch {
+	case mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		if page.Doc, err = html.Parse(body); err != nil {
+			return nil, ierrors.Wrapf(err, "failed to parse HTML of %s", urlStr)
+		}
+	case mediaType == "text/plain":
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, ierrors.Wrapf(err, "failed to read body of %s", urlStr)
+		}
+		page.Text = string(b)
+	}
+	return page, nil
+}
+
+// processSources calls process for every attribution with a URL, concurrently.
+// Failures are logged and do not stop the other attributions from being processed.
+func (c *Client) processSources(ctx context.Context, attrs []GroundingAttribution, process func(context.Context, *GroundingAttribution) error) {
+	if len(attrs) == 0 {
+		return
+	}
+
+	// Source requests share the URL resolution deadline and concurrency.
+	fetchCtx, cancel := c.createResolveContext(ctx)
+	defer cancel()
+
+	jobs := make(chan int, len(attrs))
+	done := make(chan struct{}, len(attrs))
+	for w := 0; w < min(c.config.URLResolutionConcurrency, len(attrs)); w++ {
+		go func() {
+			for i := range jobs {
+				if err := process(fetchCtx, &attrs[i]); err != nil {
+					c.logger.WarnContext(ctx, "gemini: failed to check source",
+						slog.Int("index", i+1),
+						slog.Any("error", err),
+					)
+				}
+				done <- struct{}{}
+			}
+		}()
+	}
+
+	jobCount := 0
+	for i := range attrs {
+		if attrs[i].URL != "" {
+			jobs <- i
+			jobCount++
+		}
+	}
+	close(jobs)
+
+	// Every worker reports each job, so waiting for all of them keeps attrs from being
+	// modified after processSources returns; requests end early once fetchCtx is done.
+	for range jobCount {
+		<-done
+	}
+}
+
+// enrichSource fetches the source page of attr and attaches what can be extracted from it.
+// The page's status is recorded as by validateSource.
+func (c *Client) enrichSource(ctx context.Context, attr *GroundingAttribution) error {
+	target, err := c.sourceURL(ctx, attr.URL)
+	if err != nil {
+		attr.Reachable = false
+		return err
+	}
+	release, err := c.hostLimiter.acquire(ctx, target)
+	if err != nil {
+		return err
+	}
+	page, err := fetchSource(ctx, c.resolverClient, c.resolverHeader, target)
+	release()
+	if err != nil {
+		attr.Reachable = false
+		return err
+	}
+	if err := c.recordStatus(ctx, attr, page.StatusCode, page.URL.String()); err != nil {
+		return err
+	}
+	if page.StatusCode < 200 || page.StatusCode > 299 {
+		return nil
+	}
+
+	fetchedAt := time.Now()
+	attr.FetchedAt = &fetchedAt
+
+	text := page.Text
+	if page.Doc != nil {
+		text = mainText(page.Doc)
+	}
+	attr.Snippet = snippet(text, snippetLength)
+	attr.WordCount = len(strings.Fields(text))
+
+	meta := newPageMetadata(page.Doc)
+	attr.PublishedAt = publishedAt(meta, page.Header)
+	attr.CanonicalURL = meta.link(page.URL, "canonical")
+	attr.FaviconURL = meta.favicon(page.URL)
+	attr.SiteName = meta.metas["og:site_name"]
+	attr.OGTitle = meta.metas["og:title"]
+	attr.OGDescription = meta.metas["og:description"]
+	return nil
+}
+
+// snippet returns the beginning of text, cut at a word boundary to at most n characters.
+func snippet(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	cut := string(runes[:n])
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "…"
+}
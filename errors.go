@@ -1,10 +1,20 @@
 package search
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"google.golang.org/api/iterator" // For checking if an error means "iterator done"
+	"google.golang.org/genai"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -28,6 +38,24 @@ var (
 
 	// ErrUnsupportedFunctionality is returned when a requested feature or operation is not supported.
 	ErrUnsupportedFunctionality = errors.New("gemini: unsupported functionality")
+
+	// ErrQueryRejected is returned when query moderation classifies a prompt as disallowed.
+	// The returned error is a *QueryRejectedError carrying the matched categories.
+	ErrQueryRejected = errors.New("gemini: query rejected by moderation")
+
+	// ErrClientClosed is returned by calls made on, or canceled by, a closed Client.
+	ErrClientClosed = errors.New("gemini: client is closed")
+
+	// ErrCircuitOpen is returned without contacting the API while the circuit breaker is open
+	// after repeated server errors.
+	ErrCircuitOpen = errors.New("gemini: circuit breaker is open")
+
+	// ErrNoRecording is returned in replay mode (see WithReplay) for a request that was not recorded.
+	ErrNoRecording = errors.New("gemini: no recorded response for request")
+
+	// ErrFunctionCallLimitExceeded is returned when the model keeps requesting function calls
+	// beyond the configured number of round-trips.
+	ErrFunctionCallLimitExceeded = errors.New("gemini: function call round limit exceeded")
 )
 
 // APIError represents an error returned from the Gemini API.
@@ -45,6 +73,10 @@ type APIError struct {
 
 	// Err is the underlying error, if any.
 	Err error
+
+	// RetryAfter is how long the API asked the client to wait before retrying, typically with
+	// a ResourceExhausted (quota) error. It is zero if the API did not specify a delay.
+	RetryAfter time.Duration
 }
 
 // Error implements the error interface for APIError.
@@ -60,15 +92,304 @@ func (e *APIError) Unwrap() error {
 	return e.Err
 }
 
+// BlockStage tells at which stage of a request content was blocked.
+type BlockStage string
+
+// Constants for BlockStage
+const (
+	// BlockStagePrompt means the prompt was rejected before any content was generated.
+	BlockStagePrompt BlockStage = "prompt"
+	// BlockStageCandidate means generation of the response was stopped by safety filters.
+	BlockStageCandidate BlockStage = "candidate"
+)
+
+// BlockedError is returned, wrapped in an *APIError, when the API blocks a prompt or stops a
+// response for safety or policy reasons. It matches ErrContentBlocked with errors.Is.
+type BlockedError struct {
+	// Stage tells whether the prompt or the generated candidate was blocked.
+	Stage BlockStage
+
+	// Reason is the API's reason for the block: the BlockReason for prompts (e.g., "SAFETY",
+	// "BLOCKLIST"), or the FinishReason for candidates (e.g., "SAFETY"). It may be empty if the
+	// block was reported as an API error without a reason.
+	Reason string
+
+	// Message is the API's explanation of the block, if provided.
+	Message string
+
+	// SafetyRatings lists the safety ratings of the blocked prompt or candidate. Ratings with
+	// Blocked set name the categories that caused the block.
+	SafetyRatings []SafetyRating
+}
+
+// Error implements the error interface for BlockedError.
+func (e *BlockedError) Error() string {
+	msg := fmt.Sprintf("%v (stage: %s", ErrContentBlocked, e.Stage)
+	if e.Reason != "" {
+		msg += ", reason: " + e.Reason
+	}
+	if cats := e.BlockedCategories(); len(cats) > 0 {
+		names := make([]string, len(cats))
+		for i, c := range cats {
+			names[i] = string(c)
+		}
+		msg += ", categories: " + strings.Join(names, ", ")
+	}
+	msg += ")"
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap returns ErrContentBlocked, allowing errors.Is(err, ErrContentBlocked).
+func (e *BlockedError) Unwrap() error {
+	return ErrContentBlocked
+}
+
+// BlockedCategories returns the harm categories whose safety ratings caused the block.
+func (e *BlockedError) BlockedCategories() []HarmCategory {
+	var cats []HarmCategory
+	for _, r := range e.SafetyRatings {
+		if r.Blocked {
+			cats = append(cats, r.Category)
+		}
+	}
+	return cats
+}
+
 // newAPIError creates a new APIError.
 // This is an internal helper. Users should typically rely on error checking functions.
+// Quota errors wrap originalError in a *QuotaError describing the exhausted quota.
 func newAPIError(code codes.Code, message string, originalError error, details ...interface{}) *APIError {
+	delay := retryDelay(details)
+	if code == codes.ResourceExhausted {
+		originalError = newQuotaError(details, delay, originalError)
+	}
 	return &APIError{
 		StatusCode: code,
 		Message:    message,
 		Err:        originalError,
 		Details:    details,
+		RetryAfter: delay,
+	}
+}
+
+// QuotaError is returned, wrapped in an *APIError, when a request exceeds an API quota or rate
+// limit. It describes the exhausted quota as far as the API reported it; fields the API did not
+// report are empty. Retrieve it with GetQuotaError.
+type QuotaError struct {
+	// Metric is the quota metric that was exceeded
+	// (e.g., "generativelanguage.googleapis.com/generate_content_free_tier_requests").
+	Metric string
+
+	// QuotaID identifies the exceeded limit of the metric
+	// (e.g., "GenerateRequestsPerDayPerProjectPerModel-FreeTier").
+	QuotaID string
+
+	// Dimensions are the dimensions the limit applies to, such as "model" and "location".
+	Dimensions map[string]string
+
+	// Limit is the value of the exceeded limit, or zero if it was not reported.
+	Limit int64
+
+	// Description is the API's description of the violation, if provided.
+	Description string
+
+	// RetryAfter is how long the API asked the client to wait before retrying, or zero.
+	RetryAfter time.Duration
+
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// Error implements the error interface for QuotaError.
+func (e *QuotaError) Error() string {
+	msg := "gemini: quota exceeded"
+	if e.Metric != "" {
+		msg += " for " + e.Metric
+	}
+	var info []string
+	if e.QuotaID != "" {
+		info = append(info, "quota: "+e.QuotaID)
+	}
+	if e.Limit > 0 {
+		info = append(info, fmt.Sprintf("limit: %d", e.Limit))
+	}
+	if e.RetryAfter > 0 {
+		info = append(info, "retry after: "+e.RetryAfter.String())
+	}
+	if len(info) > 0 {
+		msg += " (" + strings.Join(info, ", ") + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *QuotaError) Unwrap() error {
+	return e.Err
+}
+
+// newQuotaError creates a QuotaError from the first quota violation among details, which are
+// either gRPC status details or the decoded JSON details of a genai.APIError.
+func newQuotaError(details []interface{}, retryAfter time.Duration, err error) *QuotaError {
+	qe := &QuotaError{RetryAfter: retryAfter, Err: err}
+	for _, d := range details {
+		switch d := d.(type) {
+		case *errdetails.QuotaFailure:
+			if v := d.GetViolations(); len(v) > 0 {
+				qe.Metric = v[0].GetSubject()
+				qe.Description = v[0].GetDescription()
+				return qe
+			}
+		case map[string]any:
+			if t, _ := d["@type"].(string); !strings.HasSuffix(t, "google.rpc.QuotaFailure") {
+				continue
+			}
+			violations, _ := d["violations"].([]any)
+			if len(violations) == 0 {
+				continue
+			}
+			v, _ := violations[0].(map[string]any)
+			qe.Metric, _ = v["quotaMetric"].(string)
+			if qe.Metric == "" {
+				qe.Metric, _ = v["subject"].(string)
+			}
+			qe.QuotaID, _ = v["quotaId"].(string)
+			qe.Description, _ = v["description"].(string)
+			if dims, ok := v["quotaDimensions"].(map[string]any); ok {
+				qe.Dimensions = make(map[string]string, len(dims))
+				for k, val := range dims {
+					qe.Dimensions[k] = fmt.Sprint(val)
+				}
+			}
+			// int64 values are encoded as strings in JSON.
+			switch limit := v["quotaValue"].(type) {
+			case string:
+				qe.Limit, _ = strconv.ParseInt(limit, 10, 64)
+			case float64:
+				qe.Limit = int64(limit)
+			}
+			return qe
+		}
+	}
+	return qe
+}
+
+// retryDelay returns the delay of the RetryInfo among details, or zero if there is none.
+// Details are either gRPC status details or the decoded JSON details of a genai.APIError.
+func retryDelay(details []interface{}) time.Duration {
+	for _, d := range details {
+		switch d := d.(type) {
+		case *errdetails.RetryInfo:
+			if d.GetRetryDelay() != nil {
+				return d.GetRetryDelay().AsDuration()
+			}
+		case map[string]any:
+			if t, _ := d["@type"].(string); !strings.HasSuffix(t, "google.rpc.RetryInfo") {
+				continue
+			}
+			// Durations are encoded as strings such as "37s" or "0.5s" in JSON.
+			if s, ok := d["retryDelay"].(string); ok {
+				if delay, err := time.ParseDuration(s); err == nil && delay > 0 {
+					return delay
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// newAPIErrorFromSDK converts an error returned by the genai SDK into an *APIError. The HTTP
+// status of a genai.APIError is mapped to the corresponding gRPC code, so that the Is*Error
+// helpers and ClassifyError recognize it; other errors get codes.Unknown.
+func newAPIErrorFromSDK(err error, message string) *APIError {
+	if s, ok := status.FromError(err); ok {
+		return newAPIError(s.Code(), s.Message(), err, s.Details()...)
 	}
+	var sdkErr genai.APIError
+	if !errors.As(err, &sdkErr) {
+		return newAPIError(codes.Unknown, message, err)
+	}
+
+	details := make([]interface{}, len(sdkErr.Details))
+	for i, d := range sdkErr.Details {
+		details[i] = d
+	}
+	return newAPIError(sdkErrorCode(sdkErr), sdkErr.Message, err, details...)
+}
+
+// sdkErrorCode returns the gRPC code equivalent to the HTTP status of a genai.APIError.
+func sdkErrorCode(sdkErr genai.APIError) codes.Code {
+	code := httpStatusCode(sdkErr.Code)
+	// The Gemini API reports an invalid API key as a bad request.
+	if code == codes.InvalidArgument && hasErrorReason(sdkErr.Details, "API_KEY_INVALID") {
+		code = codes.Unauthenticated
+	}
+	return code
+}
+
+// errorCode returns the gRPC code of an API error in err's chain: a gRPC status, an *APIError,
+// or a genai.APIError returned by the SDK over HTTP, whose HTTP status is mapped to the
+// equivalent gRPC code. It returns false if err carries no API error.
+func errorCode(err error) (codes.Code, bool) {
+	if s, ok := status.FromError(err); ok {
+		return s.Code(), true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode, true
+	}
+	var sdkErr genai.APIError
+	if errors.As(err, &sdkErr) {
+		return sdkErrorCode(sdkErr), true
+	}
+	return codes.OK, false
+}
+
+// httpStatusCode maps an HTTP status code of the API to the equivalent gRPC code.
+func httpStatusCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499: // Client Closed Request
+		return codes.Canceled
+	case http.StatusInternalServerError:
+		return codes.Internal
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Unknown
+	}
+}
+
+// hasErrorReason reports whether the details of an API error include an ErrorInfo with reason.
+func hasErrorReason(details []map[string]any, reason string) bool {
+	for _, d := range details {
+		if r, ok := d["reason"].(string); ok && r == reason {
+			return true
+		}
+	}
+	return false
 }
 
 // --- Error Type Checking Helper Functions ---
@@ -89,41 +410,49 @@ func GetAPIError(err error) (*APIError, bool) {
 	return nil, false
 }
 
+// GetBlockedError attempts to retrieve a *BlockedError from the given error.
+// Returns the *BlockedError and true if successful, otherwise nil and false.
+func GetBlockedError(err error) (*BlockedError, bool) {
+	var blocked *BlockedError
+	if errors.As(err, &blocked) {
+		return blocked, true
+	}
+	return nil, false
+}
+
+// GetQuotaError attempts to retrieve a *QuotaError from the given error.
+// Returns the *QuotaError and true if successful, otherwise nil and false.
+func GetQuotaError(err error) (*QuotaError, bool) {
+	var quotaErr *QuotaError
+	if errors.As(err, &quotaErr) {
+		return quotaErr, true
+	}
+	return nil, false
+}
+
 // IsAuthenticationError checks if an error is due to authentication issues (e.g., invalid API key).
-// These typically correspond to gRPC codes Unauthenticated or PermissionDenied.
+// These typically correspond to gRPC codes Unauthenticated or PermissionDenied (HTTP 401 or 403).
 func IsAuthenticationError(err error) bool {
-	if s, ok := status.FromError(err); ok {
-		return s.Code() == codes.Unauthenticated || s.Code() == codes.PermissionDenied
-	}
-	var apiErr *APIError
-	if errors.As(err, &apiErr) {
-		return apiErr.StatusCode == codes.Unauthenticated || apiErr.StatusCode == codes.PermissionDenied
+	if code, ok := errorCode(err); ok {
+		return code == codes.Unauthenticated || code == codes.PermissionDenied
 	}
 	return errors.Is(err, ErrMissingAPIKey) // Also consider client-side missing key
 }
 
 // IsQuotaError checks if an error is due to quota exhaustion or rate limiting.
-// This typically corresponds to gRPC code ResourceExhausted.
+// This typically corresponds to gRPC code ResourceExhausted (HTTP 429).
 func IsQuotaError(err error) bool {
-	if s, ok := status.FromError(err); ok {
-		return s.Code() == codes.ResourceExhausted
-	}
-	var apiErr *APIError
-	if errors.As(err, &apiErr) {
-		return apiErr.StatusCode == codes.ResourceExhausted
+	if code, ok := errorCode(err); ok {
+		return code == codes.ResourceExhausted
 	}
 	return false
 }
 
 // IsInvalidRequestError checks if an error is due to an invalid request (e.g., malformed parameters).
-// This typically corresponds to gRPC code InvalidArgument.
+// This typically corresponds to gRPC code InvalidArgument (HTTP 400).
 func IsInvalidRequestError(err error) bool {
-	if s, ok := status.FromError(err); ok {
-		return s.Code() == codes.InvalidArgument
-	}
-	var apiErr *APIError
-	if errors.As(err, &apiErr) {
-		return apiErr.StatusCode == codes.InvalidArgument
+	if code, ok := errorCode(err); ok {
+		return code == codes.InvalidArgument
 	}
 	return errors.Is(err, ErrInvalidParameter) || errors.Is(err, ErrInvalidModelName)
 }
@@ -137,18 +466,90 @@ func IsContentBlockedError(err error) bool {
 }
 
 // IsServerError checks if an error is a server-side error from the Gemini API.
-// These typically correspond to gRPC codes Internal, Unavailable, or Unknown.
+// These typically correspond to gRPC codes Internal, Unavailable, or Unknown (HTTP 500, 502, or 503).
 func IsServerError(err error) bool {
-	if s, ok := status.FromError(err); ok {
-		return s.Code() == codes.Internal || s.Code() == codes.Unavailable || s.Code() == codes.Unknown
+	if code, ok := errorCode(err); ok {
+		return code == codes.Internal || code == codes.Unavailable || code == codes.Unknown
 	}
-	var apiErr *APIError
-	if errors.As(err, &apiErr) {
-		return apiErr.StatusCode == codes.Internal || apiErr.StatusCode == codes.Unavailable || apiErr.StatusCode == codes.Unknown
+	return false
+}
+
+// IsTimeoutError checks if an error is due to a timeout: an exceeded context deadline
+// (e.g., from WithRequestTimeout), a DeadlineExceeded error from the API, or a network timeout.
+func IsTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if code, ok := errorCode(err); ok && code == codes.DeadlineExceeded {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsRetryableError checks if a request that failed with err may succeed when retried after a
+// backoff: timeouts, connections reset by the network, and API errors with the gRPC codes
+// Unavailable or ResourceExhausted (HTTP 503 or 429). For quota errors, wait at least
+// APIError.RetryAfter. Cancellation, a closed client, and an open circuit breaker are not retryable.
+func IsRetryableError(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled) || errors.Is(err, ErrClientClosed) || errors.Is(err, ErrCircuitOpen):
+		return false
+	case IsTimeoutError(err):
+		return true
+	case errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+	if code, ok := errorCode(err); ok {
+		return code == codes.Unavailable || code == codes.ResourceExhausted
 	}
 	return false
 }
 
+// ErrorClass is a coarse classification of an error, suitable for metrics labels and alerting.
+type ErrorClass string
+
+// Constants for ErrorClass
+const (
+	ErrorClassNone           ErrorClass = ""
+	ErrorClassAuthentication ErrorClass = "authentication"
+	ErrorClassQuota          ErrorClass = "quota"
+	ErrorClassInvalidRequest ErrorClass = "invalid_request"
+	ErrorClassContentBlocked ErrorClass = "content_blocked"
+	ErrorClassServer         ErrorClass = "server"
+	ErrorClassCanceled       ErrorClass = "canceled"
+	ErrorClassTimeout        ErrorClass = "timeout"
+	ErrorClassUnknown        ErrorClass = "unknown"
+)
+
+// ClassifyError returns the ErrorClass of err using the Is*Error helpers.
+// It returns ErrorClassNone for a nil error.
+func ClassifyError(err error) ErrorClass {
+	switch {
+	case err == nil:
+		return ErrorClassNone
+	case errors.Is(err, context.Canceled) || errors.Is(err, ErrClientClosed):
+		return ErrorClassCanceled
+	case IsTimeoutError(err):
+		return ErrorClassTimeout
+	case IsContentBlockedError(err) || errors.Is(err, ErrQueryRejected):
+		return ErrorClassContentBlocked
+	case IsAuthenticationError(err):
+		return ErrorClassAuthentication
+	case IsQuotaError(err):
+		return ErrorClassQuota
+	case IsInvalidRequestError(err):
+		return ErrorClassInvalidRequest
+	case IsServerError(err):
+		return ErrorClassServer
+	default:
+		return ErrorClassUnknown
+	}
+}
+
 // IsIteratorDone is a helper to check for the specific error returned by the genai SDK
 // when a streaming iterator (like for GenerateContentStream) is finished.
 // While our library might not expose streaming directly initially, this can be useful.
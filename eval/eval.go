@@ -0,0 +1,309 @@
+/*
+Package eval provides a small harness for evaluating grounded search quality.
+
+It runs a labeled set of cases (a query plus the facts and sources a good answer
+is expected to contain) against one or more client configurations and reports
+answer accuracy, citation precision/recall, latency, and token cost, so prompt,
+template, model, or SDK changes can be regression-tested.
+
+Basic Usage:
+
+	cases := []eval.Case{
+		{
+			Query:         "Who won the 2022 FIFA World Cup?",
+			ExpectedFacts: []string{"Argentina"},
+			ExpectedURLs:  []string{"fifa.com"},
+		},
+	}
+	report, err := eval.Run(ctx, client, eval.Config{Name: "flash"}, cases)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("accuracy=%.2f recall=%.2f\n", report.Accuracy, report.CitationRecall)
+*/
+package eval
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	search "github.com/cnosuke/go-gemini-grounded-search"
+)
+
+// Generator is the subset of *search.Client used by the harness.
+type Generator interface {
+	GenerateGroundedContentWithParams(ctx context.Context, params *search.GenerationParams) (*search.Response, error)
+}
+
+// Case is a single labeled evaluation case.
+type Case struct {
+	// Name identifies the case in reports. Defaults to Query if empty.
+	Name string `json:"name,omitempty"`
+
+	// Query is the prompt sent to the model.
+	Query string `json:"query"`
+
+	// ExpectedFacts lists strings a correct answer must contain (matched case-insensitively).
+	ExpectedFacts []string `json:"expected_facts,omitempty"`
+
+	// ExpectedURLs lists sources a good answer should cite. Each entry is either a full URL
+	// or a bare domain (e.g., "who.int"), which matches any URL on that domain or its subdomains.
+	ExpectedURLs []string `json:"expected_urls,omitempty"`
+}
+
+// Pricing is the cost of a model in currency units per one million tokens.
+type Pricing struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// Config describes the client configuration under evaluation.
+type Config struct {
+	// Name identifies the configuration in reports.
+	Name string `json:"name"`
+
+	// Params is used as a template for every case; its Prompt is replaced by the case query,
+	// and URL resolution is enabled for cases with ExpectedURLs.
+	// If nil, the client's defaults are used.
+	Params *search.GenerationParams `json:"-"`
+
+	// Pricing is used to compute cost. If nil, cost is reported as zero.
+	Pricing *Pricing `json:"pricing,omitempty"`
+}
+
+// CaseResult is the outcome of a single case.
+type CaseResult struct {
+	Name            string        `json:"name"`
+	Err             error         `json:"-"`
+	Error           string        `json:"error,omitempty"`
+	Answer          string        `json:"answer,omitempty"`
+	CitedURLs       []string      `json:"cited_urls,omitempty"`
+	FactsFound      int           `json:"facts_found"`
+	FactsExpected   int           `json:"facts_expected"`
+	Accuracy        float64       `json:"accuracy"`
+	CitationPrec    float64       `json:"citation_precision"`
+	CitationRecall  float64       `json:"citation_recall"`
+	Latency         time.Duration `json:"latency"`
+	InputTokens     int64         `json:"input_tokens"`
+	OutputTokens    int64         `json:"output_tokens"`
+	Cost            float64       `json:"cost"`
+	MissingFacts    []string      `json:"missing_facts,omitempty"`
+	MissingSources  []string      `json:"missing_sources,omitempty"`
+	UnexpectedURLs  []string      `json:"unexpected_urls,omitempty"`
+	HasExpectedURLs bool          `json:"-"`
+}
+
+// Report aggregates the results of a run.
+type Report struct {
+	Config Config       `json:"config"`
+	Cases  []CaseResult `json:"cases"`
+
+	// Errors is the number of cases whose request failed.
+	Errors int `json:"errors"`
+
+	// Accuracy is the mean fraction of expected facts found, over cases with expected facts.
+	// Failed cases score zero.
+	Accuracy float64 `json:"accuracy"`
+
+	// CitationPrecision and CitationRecall are averaged over cases with expected URLs.
+	CitationPrecision float64 `json:"citation_precision"`
+	CitationRecall    float64 `json:"citation_recall"`
+
+	// MeanLatency and MaxLatency summarize request latency over successful cases.
+	MeanLatency time.Duration `json:"mean_latency"`
+	MaxLatency  time.Duration `json:"max_latency"`
+
+	// TotalCost is the summed cost of all cases.
+	TotalCost float64 `json:"total_cost"`
+}
+
+// Run evaluates every case against g using cfg and returns the aggregated report.
+// Failed requests are recorded in the report rather than aborting the run;
+// Run only returns an error if ctx is canceled or there are no cases.
+func Run(ctx context.Context, g Generator, cfg Config, cases []Case) (*Report, error) {
+	if len(cases) == 0 {
+		return nil, errors.New("eval: no cases to run")
+	}
+
+	report := &Report{Config: cfg, Cases: make([]CaseResult, 0, len(cases))}
+	for _, c := range cases {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report.Cases = append(report.Cases, runCase(ctx, g, cfg, c))
+	}
+	report.aggregate()
+	return report, nil
+}
+
+// RunAll evaluates the same cases against several configurations, e.g., to compare models.
+func RunAll(ctx context.Context, g Generator, cfgs []Config, cases []Case) ([]*Report, error) {
+	reports := make([]*Report, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		r, err := Run(ctx, g, cfg, cases)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+func runCase(ctx context.Context, g Generator, cfg Config, c Case) CaseResult {
+	res := CaseResult{Name: c.Name, FactsExpected: len(c.ExpectedFacts), HasExpectedURLs: len(c.ExpectedURLs) > 0}
+	if res.Name == "" {
+		res.Name = c.Query
+	}
+
+	params := &search.GenerationParams{}
+	if cfg.Params != nil {
+		p := *cfg.Params
+		params = &p
+	}
+	params.Prompt = c.Query
+	if res.HasExpectedURLs {
+		// Cited URLs are matched against expected ones, which requires their origin URLs
+		// rather than grounding redirect URLs.
+		resolve := true
+		params.ResolveURLs = &resolve
+	}
+
+	start := time.Now()
+	resp, err := g.GenerateGroundedContentWithParams(ctx, params)
+	res.Latency = time.Since(start)
+	if err != nil {
+		res.Err = err
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Answer = resp.GeneratedText
+	for _, attr := range resp.GroundingAttributions {
+		if attr.URL != "" {
+			res.CitedURLs = append(res.CitedURLs, attr.URL)
+		}
+	}
+
+	scoreFacts(&res, c.ExpectedFacts)
+	scoreCitations(&res, c.ExpectedURLs)
+
+	if resp.RawResponse != nil && resp.RawResponse.UsageMetadata != nil {
+		res.InputTokens = int64(resp.RawResponse.UsageMetadata.PromptTokenCount)
+		res.OutputTokens = int64(resp.RawResponse.UsageMetadata.CandidatesTokenCount) + int64(resp.RawResponse.UsageMetadata.ThoughtsTokenCount)
+	}
+	if cfg.Pricing != nil {
+		res.Cost = float64(res.InputTokens)/1e6*cfg.Pricing.InputPerMillion + float64(res.OutputTokens)/1e6*cfg.Pricing.OutputPerMillion
+	}
+	return res
+}
+
+func scoreFacts(res *CaseResult, facts []string) {
+	if len(facts) == 0 {
+		return
+	}
+	answer := strings.ToLower(res.Answer)
+	for _, f := range facts {
+		if strings.Contains(answer, strings.ToLower(f)) {
+			res.FactsFound++
+		} else {
+			res.MissingFacts = append(res.MissingFacts, f)
+		}
+	}
+	res.Accuracy = float64(res.FactsFound) / float64(len(facts))
+}
+
+func scoreCitations(res *CaseResult, expected []string) {
+	if len(expected) == 0 {
+		return
+	}
+	matchedExpected := 0
+	for _, e := range expected {
+		found := false
+		for _, u := range res.CitedURLs {
+			if urlMatches(u, e) {
+				found = true
+				break
+			}
+		}
+		if found {
+			matchedExpected++
+		} else {
+			res.MissingSources = append(res.MissingSources, e)
+		}
+	}
+	res.CitationRecall = float64(matchedExpected) / float64(len(expected))
+
+	if len(res.CitedURLs) == 0 {
+		return
+	}
+	relevant := 0
+	for _, u := range res.CitedURLs {
+		ok := false
+		for _, e := range expected {
+			if urlMatches(u, e) {
+				ok = true
+				break
+			}
+		}
+		if ok {
+			relevant++
+		} else {
+			res.UnexpectedURLs = append(res.UnexpectedURLs, u)
+		}
+	}
+	res.CitationPrec = float64(relevant) / float64(len(res.CitedURLs))
+}
+
+// urlMatches reports whether cited matches expected, which is either a full URL or a bare domain.
+func urlMatches(cited, expected string) bool {
+	if strings.Contains(expected, "://") {
+		return search.CanonicalURL(cited) == search.CanonicalURL(expected)
+	}
+	u, err := url.Parse(cited)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	domain := strings.ToLower(strings.TrimPrefix(expected, "www."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func (r *Report) aggregate() {
+	var accN, citeN, latN int
+	var latSum time.Duration
+	for _, c := range r.Cases {
+		r.TotalCost += c.Cost
+		// Failed cases count as zero accuracy and citation scores but are excluded from latency.
+		if c.Err != nil {
+			r.Errors++
+		} else {
+			latN++
+			latSum += c.Latency
+			if c.Latency > r.MaxLatency {
+				r.MaxLatency = c.Latency
+			}
+		}
+		if c.FactsExpected > 0 {
+			accN++
+			r.Accuracy += c.Accuracy
+		}
+		if c.HasExpectedURLs {
+			citeN++
+			r.CitationPrecision += c.CitationPrec
+			r.CitationRecall += c.CitationRecall
+		}
+	}
+	if accN > 0 {
+		r.Accuracy /= float64(accN)
+	}
+	if citeN > 0 {
+		r.CitationPrecision /= float64(citeN)
+		r.CitationRecall /= float64(citeN)
+	}
+	if latN > 0 {
+		r.MeanLatency = latSum / time.Duration(latN)
+	}
+}
@@ -0,0 +1,164 @@
+package search
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements are elements whose content is never part of a page's main text.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Select: true, atom.Iframe: true,
+	atom.Svg: true, atom.Canvas: true, atom.Object: true,
+}
+
+// blockElements are elements that start a new line in extracted text.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Ul: true, atom.Ol: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Table: true, atom.Tr: true, atom.Br: true,
+	atom.Figcaption: true,
+}
+
+// mainText extracts the main text of a page, readability-style: the content of its <article>
+// or <main> element if it has one, otherwise of the element containing the most paragraph text.
+// Navigation, headers, footers, scripts, and similar boilerplate are left out.
+func mainText(doc *html.Node) string {
+	root := findElement(doc, func(n *html.Node) bool {
+		return n.DataAtom == atom.Article || n.DataAtom == atom.Main || htmlAttr(n, "role") == "main"
+	})
+	if root == nil {
+		root = densestElement(doc)
+	}
+	if root == nil {
+		root = findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Body })
+	}
+	if root == nil {
+		return ""
+	}
+
+	var lines []string
+	var line strings.Builder
+	flush := func() {
+		if s := strings.Join(strings.Fields(line.String()), " "); s != "" {
+			lines = append(lines, s)
+		}
+		line.Reset()
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			line.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if skippedElements[n.DataAtom] || hasHTMLAttr(n, "hidden") || htmlAttr(n, "aria-hidden") == "true" {
+				return
+			}
+		}
+		block := n.Type == html.ElementNode && blockElements[n.DataAtom]
+		if block {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			flush()
+		}
+	}
+	walk(root)
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// densestElement returns the element whose paragraphs hold the most text, counting a paragraph
+// fully towards its parent and half towards its grandparent. It returns nil if the page has no
+// paragraphs of meaningful length.
+func densestElement(doc *html.Node) *html.Node {
+	const minParagraphLength = 25
+	scores := make(map[*html.Node]int)
+	var order []*html.Node // scored elements in document order, so ties are broken deterministically
+	add := func(n *html.Node, score int) {
+		if _, ok := scores[n]; !ok {
+			order = append(order, n)
+		}
+		scores[n] += score
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && skippedElements[n.DataAtom] {
+			return
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.P {
+			if l := len(strings.Join(strings.Fields(textContent(n)), " ")); l >= minParagraphLength && n.Parent != nil {
+				add(n.Parent, l)
+				if n.Parent.Parent != nil {
+					add(n.Parent.Parent, l/2)
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var best *html.Node
+	for _, n := range order {
+		if best == nil || scores[n] > scores[best] {
+			best = n
+		}
+	}
+	return best
+}
+
+// findElement returns the first element, in document order, for which match returns true.
+func findElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// textContent returns the concatenated text of n and its descendants.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// htmlAttr returns the value of the attribute key of n, or an empty string if it is not set.
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasHTMLAttr reports whether the attribute key is set on n.
+func hasHTMLAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
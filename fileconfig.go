@@ -0,0 +1,263 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig is the declarative client configuration read by LoadClientConfig.
+// Unset fields leave the corresponding defaults unchanged. Durations are strings accepted by
+// time.ParseDuration (e.g., "30s"). The API key is not part of the file; pass it to NewClient.
+//
+// An example YAML file:
+//
+//	model: gemini-3.1-pro-preview
+//	temperature: 0.2
+//	max_output_tokens: 2048
+//	thinking_level: LOW
+//	safety_preset: balanced
+//	request_timeout: 60s
+//	no_redirection: true
+//	rate_limit:
+//	  requests_per_second: 5
+//	  burst: 10
+type FileConfig struct {
+	// Model is the default model. See WithModelName.
+	Model string `json:"model,omitempty" yaml:"model,omitempty"`
+
+	// Temperature, MaxOutputTokens, TopK, TopP, and Seed set the default generation parameters.
+	Temperature     *float32 `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	MaxOutputTokens *int32   `json:"max_output_tokens,omitempty" yaml:"max_output_tokens,omitempty"`
+	TopK            *int32   `json:"top_k,omitempty" yaml:"top_k,omitempty"`
+	TopP            *float32 `json:"top_p,omitempty" yaml:"top_p,omitempty"`
+	Seed            *int32   `json:"seed,omitempty" yaml:"seed,omitempty"`
+
+	// ThinkingLevel and ThinkingBudget set the default thinking configuration.
+	ThinkingLevel  ThinkingLevel `json:"thinking_level,omitempty" yaml:"thinking_level,omitempty"`
+	ThinkingBudget *int32        `json:"thinking_budget,omitempty" yaml:"thinking_budget,omitempty"`
+
+	// SafetyPreset is "strict", "balanced", or "none" (see SafetyPresetStrict and its siblings).
+	// SafetySettings are applied after the preset and override it for their categories.
+	SafetyPreset   string          `json:"safety_preset,omitempty" yaml:"safety_preset,omitempty"`
+	SafetySettings []SafetySetting `json:"safety_settings,omitempty" yaml:"safety_settings,omitempty"`
+
+	// RequestTimeout and SoftTimeout are durations. See WithRequestTimeout and WithSoftTimeout.
+	RequestTimeout string `json:"request_timeout,omitempty" yaml:"request_timeout,omitempty"`
+	SoftTimeout    string `json:"soft_timeout,omitempty" yaml:"soft_timeout,omitempty"`
+
+	// NoRedirection and OfflineURLDecoding configure the resolution of grounding redirect URLs.
+	NoRedirection      bool `json:"no_redirection,omitempty" yaml:"no_redirection,omitempty"`
+	OfflineURLDecoding bool `json:"offline_url_decoding,omitempty" yaml:"offline_url_decoding,omitempty"`
+
+	// RateLimit and CircuitBreaker configure how the client protects itself and the API from
+	// overload. See WithRateLimit and WithCircuitBreaker.
+	RateLimit      *FileRateLimit      `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	CircuitBreaker *FileCircuitBreaker `json:"circuit_breaker,omitempty" yaml:"circuit_breaker,omitempty"`
+
+	// Proxy is the URL of the HTTP proxy for all outgoing requests. See WithProxy.
+	Proxy string `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+
+	// BaseURL and Headers route API requests through a gateway. See WithBaseURL and WithHTTPOptions.
+	BaseURL string            `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// RequestCompression gzip-compresses API request bodies. See WithRequestCompression.
+	RequestCompression bool `json:"request_compression,omitempty" yaml:"request_compression,omitempty"`
+
+	// UserAgentSuffix is appended to the User-Agent. See WithUserAgentSuffix.
+	UserAgentSuffix string `json:"user_agent_suffix,omitempty" yaml:"user_agent_suffix,omitempty"`
+
+	// AllowedDomains and BlockedDomains filter cited sources. See WithAllowedDomains.
+	AllowedDomains []string `json:"allowed_domains,omitempty" yaml:"allowed_domains,omitempty"`
+	BlockedDomains []string `json:"blocked_domains,omitempty" yaml:"blocked_domains,omitempty"`
+}
+
+// FileRateLimit is the rate limit section of a FileConfig.
+type FileRateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second" yaml:"requests_per_second"`
+	Burst             int     `json:"burst" yaml:"burst"`
+}
+
+// FileCircuitBreaker is the circuit breaker section of a FileConfig.
+type FileCircuitBreaker struct {
+	Threshold int    `json:"threshold" yaml:"threshold"`
+	Cooldown  string `json:"cooldown" yaml:"cooldown"`
+}
+
+// LoadClientConfig reads a YAML (.yaml, .yml) or JSON (.json) configuration file and returns
+// the options it describes, to be passed to NewClient, possibly followed by further options
+// that take precedence. Unknown fields are rejected, so typos do not go unnoticed.
+func LoadClientConfig(path string) ([]ClientOption, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ierrors.Wrapf(err, "failed to read client config %s", path)
+	}
+
+	var fc FileConfig
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&fc); err != nil {
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "failed to parse client config %s: %v", path, err)
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&fc); err != nil {
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "failed to parse client config %s: %v", path, err)
+		}
+	default:
+		return nil, ierrors.Wrapf(ErrInvalidParameter, "unsupported client config format %q; use .yaml, .yml, or .json", ext)
+	}
+
+	opts, err := fc.Options()
+	if err != nil {
+		return nil, ierrors.Wrapf(err, "invalid client config %s", path)
+	}
+	return opts, nil
+}
+
+// Options converts the configuration into client options. Values are validated by the options
+// themselves when they are applied by NewClient; durations and the safety preset are checked here.
+func (fc *FileConfig) Options() ([]ClientOption, error) {
+	var opts []ClientOption
+	if fc.Model != "" {
+		opts = append(opts, WithModelName(fc.Model))
+	}
+	if fc.Temperature != nil {
+		opts = append(opts, WithDefaultTemperature(*fc.Temperature))
+	}
+	if fc.MaxOutputTokens != nil {
+		opts = append(opts, WithDefaultMaxOutputTokens(*fc.MaxOutputTokens))
+	}
+	if fc.TopK != nil {
+		opts = append(opts, WithDefaultTopK(*fc.TopK))
+	}
+	if fc.TopP != nil {
+		opts = append(opts, WithDefaultTopP(*fc.TopP))
+	}
+	if fc.Seed != nil {
+		opts = append(opts, WithDefaultSeed(*fc.Seed))
+	}
+	if fc.ThinkingLevel != "" || fc.ThinkingBudget != nil {
+		opts = append(opts, WithDefaultThinkingConfig(&ThinkingConfig{
+			ThinkingLevel:  ThinkingLevel(strings.ToUpper(string(fc.ThinkingLevel))),
+			ThinkingBudget: fc.ThinkingBudget,
+		}))
+	}
+
+	safety, err := fc.safetySettings()
+	if err != nil {
+		return nil, err
+	}
+	if len(safety) > 0 {
+		opts = append(opts, WithDefaultSafetySettings(safety))
+	}
+
+	if fc.RequestTimeout != "" {
+		d, err := parseConfigDuration("request_timeout", fc.RequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithRequestTimeout(d))
+	}
+	if fc.SoftTimeout != "" {
+		d, err := parseConfigDuration("soft_timeout", fc.SoftTimeout)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithSoftTimeout(d))
+	}
+
+	if fc.NoRedirection {
+		opts = append(opts, WithNoRedirection())
+	}
+	if fc.OfflineURLDecoding {
+		opts = append(opts, WithOfflineURLDecoding())
+	}
+	if fc.RateLimit != nil {
+		opts = append(opts, WithRateLimit(fc.RateLimit.RequestsPerSecond, fc.RateLimit.Burst))
+	}
+	if fc.CircuitBreaker != nil {
+		d, err := parseConfigDuration("circuit_breaker.cooldown", fc.CircuitBreaker.Cooldown)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithCircuitBreaker(fc.CircuitBreaker.Threshold, d))
+	}
+	if fc.Proxy != "" {
+		opts = append(opts, WithProxy(fc.Proxy))
+	}
+	if fc.BaseURL != "" {
+		opts = append(opts, WithBaseURL(fc.BaseURL))
+	}
+	if len(fc.Headers) > 0 {
+		headers := make(http.Header, len(fc.Headers))
+		for k, v := range fc.Headers {
+			headers.Set(k, v)
+		}
+		opts = append(opts, WithHTTPOptions(HTTPOptions{Headers: headers}))
+	}
+	if fc.RequestCompression {
+		opts = append(opts, WithRequestCompression())
+	}
+	if fc.UserAgentSuffix != "" {
+		opts = append(opts, WithUserAgentSuffix(fc.UserAgentSuffix))
+	}
+	if len(fc.AllowedDomains) > 0 {
+		opts = append(opts, WithAllowedDomains(fc.AllowedDomains...))
+	}
+	if len(fc.BlockedDomains) > 0 {
+		opts = append(opts, WithBlockedDomains(fc.BlockedDomains...))
+	}
+	return opts, nil
+}
+
+// safetySettings returns the preset's settings with SafetySettings applied on top.
+func (fc *FileConfig) safetySettings() ([]*SafetySetting, error) {
+	var settings []*SafetySetting
+	switch strings.ToLower(fc.SafetyPreset) {
+	case "":
+	case "strict":
+		settings = SafetyPresetStrict()
+	case "balanced":
+		settings = SafetyPresetBalanced()
+	case "none":
+		settings = SafetyPresetNone()
+	default:
+		return nil, ierrors.Wrapf(ErrInvalidParameter, "unknown safety preset %q; use strict, balanced, or none", fc.SafetyPreset)
+	}
+
+	for _, s := range fc.SafetySettings {
+		replaced := false
+		for i, existing := range settings {
+			if existing.Category == s.Category {
+				settings[i] = &SafetySetting{Category: s.Category, Threshold: s.Threshold}
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			settings = append(settings, &SafetySetting{Category: s.Category, Threshold: s.Threshold})
+		}
+	}
+	return settings, nil
+}
+
+// parseConfigDuration parses the duration value of a configuration field.
+func parseConfigDuration(field, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, ierrors.Wrapf(ErrInvalidParameter, "%s must be a duration such as \"30s\", got %q", field, value)
+	}
+	return d, nil
+}
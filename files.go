@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"google.golang.org/genai"
+)
+
+// filePollInterval is how often UploadFile checks whether an uploaded file has been processed.
+const filePollInterval = time.Second
+
+// File is a document attached to a request via GenerationParams.Files, such as a PDF uploaded
+// with Client.UploadFile or, on Vertex AI, a Cloud Storage object.
+type File struct {
+	// Name is the resource name of an uploaded file (e.g., "files/abc-123"), used to delete it.
+	// It is empty for files not uploaded with the Files API.
+	Name string `json:"name,omitempty"`
+
+	// URI is the URI the model reads the file from.
+	URI string `json:"uri"`
+
+	// MIMEType is the MIME type of the file (e.g., "application/pdf").
+	MIMEType string `json:"mime_type"`
+
+	// DisplayName is the human-readable name of the file.
+	DisplayName string `json:"display_name,omitempty"`
+
+	// ExpiresAt is when an uploaded file is deleted by the service. It is the zero time if unknown.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// UploadFile uploads a document (e.g., a PDF) with the Files API so that it can be attached to
+// requests via GenerationParams.Files, and waits until it has been processed. The service deletes
+// uploaded files after a while (see File.ExpiresAt); DeleteFile deletes them earlier.
+// mimeType is required. The Files API is only available on the Gemini API backend.
+func (c *Client) UploadFile(ctx context.Context, r io.Reader, mimeType, displayName string) (*File, error) {
+	if mimeType == "" {
+		return nil, ierrors.Wrap(ErrInvalidParameter, "MIME type of the uploaded file cannot be empty")
+	}
+	return c.uploadFile(ctx, func(ctx context.Context) (*genai.File, error) {
+		return c.genaiClient.Files.Upload(ctx, r, &genai.UploadFileConfig{MIMEType: mimeType, DisplayName: displayName})
+	})
+}
+
+// UploadFileFromPath uploads the file at path like UploadFile, inferring its MIME type from
+// the file extension.
+func (c *Client) UploadFileFromPath(ctx context.Context, path string) (*File, error) {
+	return c.uploadFile(ctx, func(ctx context.Context) (*genai.File, error) {
+		return c.genaiClient.Files.UploadFromPath(ctx, path, nil)
+	})
+}
+
+// DeleteFile deletes a file uploaded with UploadFile, given its Name.
+func (c *Client) DeleteFile(ctx context.Context, name string) error {
+	if c.config.Backend == BackendVertexAI {
+		return ierrors.Wrap(ErrUnsupportedFunctionality, "the Files API is not available on the Vertex AI backend")
+	}
+	ctx, cancel, err := c.bindToClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	if _, err := c.genaiClient.Files.Delete(ctx, name, nil); err != nil {
+		return ierrors.Wrapf(c.closedError(err), "failed to delete file %s", name)
+	}
+	return nil
+}
+
+// uploadFile runs upload and waits until the uploaded file is ready to use.
+func (c *Client) uploadFile(ctx context.Context, upload func(context.Context) (*genai.File, error)) (*File, error) {
+	if c.config.Backend == BackendVertexAI {
+		return nil, ierrors.Wrap(ErrUnsupportedFunctionality, "the Files API is not available on the Vertex AI backend")
+	}
+	ctx, cancel, err := c.bindToClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	f, err := upload(ctx)
+	if err != nil {
+		return nil, ierrors.Wrap(c.closedError(err), "failed to upload file")
+	}
+	for f.State == genai.FileStateProcessing {
+		select {
+		case <-ctx.Done():
+			return nil, ierrors.Wrapf(c.closedError(ctx.Err()), "file %s is still being processed", f.Name)
+		case <-time.After(filePollInterval):
+		}
+		if f, err = c.genaiClient.Files.Get(ctx, f.Name, nil); err != nil {
+			return nil, ierrors.Wrap(c.closedError(err), "failed to get uploaded file")
+		}
+	}
+	if f.State == genai.FileStateFailed {
+		msg := "processing failed"
+		if f.Error != nil && f.Error.Message != "" {
+			msg = f.Error.Message
+		}
+		return nil, fmt.Errorf("uploaded file %s could not be processed: %s", f.Name, msg)
+	}
+
+	return &File{
+		Name:        f.Name,
+		URI:         f.URI,
+		MIMEType:    f.MIMEType,
+		DisplayName: f.DisplayName,
+		ExpiresAt:   f.ExpirationTime,
+	}, nil
+}
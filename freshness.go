@@ -0,0 +1,75 @@
+package search
+
+import (
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"google.golang.org/genai"
+)
+
+// Freshness restricts the sources of an answer to recent ones (see GenerationParams.Freshness).
+// Set MaxAge for a rolling window, e.g., 24 * time.Hour for the last day, or Since for a fixed
+// date. If both are set, the later cutoff applies.
+type Freshness struct {
+	// MaxAge is the maximum age of sources at the time of the request.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+
+	// Since is the earliest publication time of sources.
+	Since time.Time `json:"since,omitempty"`
+}
+
+// cutoff returns the earliest publication time of sources for a request made at now.
+func (f *Freshness) cutoff(now time.Time) time.Time {
+	cutoff := f.Since
+	if f.MaxAge > 0 {
+		if c := now.Add(-f.MaxAge); c.After(cutoff) {
+			cutoff = c
+		}
+	}
+	return cutoff
+}
+
+// validate checks that f sets a cutoff in the past.
+func (f *Freshness) validate(now time.Time) error {
+	if f.MaxAge < 0 {
+		return ierrors.Wrapf(ErrInvalidParameter, "freshness max age cannot be negative, got %s", f.MaxAge)
+	}
+	cutoff := f.cutoff(now)
+	if cutoff.IsZero() {
+		return ierrors.Wrap(ErrInvalidParameter, "freshness requires a max age or a since date")
+	}
+	if !cutoff.Before(now) {
+		return ierrors.Wrapf(ErrInvalidParameter, "freshness since date must be in the past, got %s", cutoff.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// withSearchTimeRange returns a copy of tools whose Google Search Tool only searches content
+// from the interval [start, end).
+func withSearchTimeRange(tools []*genai.Tool, start, end time.Time) []*genai.Tool {
+	out := make([]*genai.Tool, len(tools))
+	for i, t := range tools {
+		if t != nil && t.GoogleSearch != nil {
+			search := *t.GoogleSearch
+			search.TimeRangeFilter = &genai.Interval{StartTime: start, EndTime: end}
+			tool := *t
+			tool.GoogleSearch = &search
+			t = &tool
+		}
+		out[i] = t
+	}
+	return out
+}
+
+// splitStaleAttributions separates the attributions published before cutoff from the others.
+// Attributions without a known publication date are kept.
+func splitStaleAttributions(attrs []GroundingAttribution, cutoff time.Time) (fresh, stale []GroundingAttribution) {
+	for _, attr := range attrs {
+		if attr.PublishedAt != nil && attr.PublishedAt.Before(cutoff) {
+			stale = append(stale, attr)
+		} else {
+			fresh = append(fresh, attr)
+		}
+	}
+	return fresh, stale
+}
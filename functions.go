@@ -0,0 +1,142 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"google.golang.org/genai"
+)
+
+// FunctionHandler executes a function call requested by the model.
+// args holds the arguments chosen by the model, as described by the function's declaration.
+// The returned map is sent back to the model as the function response.
+// A returned error is reported to the model (and recorded in FunctionCallResult.Error)
+// rather than aborting the generation.
+type FunctionHandler func(ctx context.Context, args map[string]any) (map[string]any, error)
+
+// Function is a user-defined function the model may call alongside the Google Search Tool.
+type Function struct {
+	// Declaration describes the function's name, purpose, and parameters to the model.
+	Declaration *genai.FunctionDeclaration
+
+	// Handler is invoked when the model calls the function.
+	Handler FunctionHandler
+}
+
+// FunctionCallResult records a single function call made by the model during generation.
+type FunctionCallResult struct {
+	// ID is the identifier the model assigned to the call, if any.
+	ID string `json:"id,omitempty"`
+
+	// Name is the name of the called function.
+	Name string `json:"name"`
+
+	// Args holds the arguments passed by the model.
+	Args map[string]any `json:"args,omitempty"`
+
+	// Result is the value returned by the handler. It is nil if the handler failed.
+	Result map[string]any `json:"result,omitempty"`
+
+	// Error is the handler's error message, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// validateFunctions checks that every function has a named declaration and a handler,
+// and that no function name is registered twice.
+func validateFunctions(fns []Function, registered map[string]Function) error {
+	seen := make(map[string]bool, len(fns))
+	for _, fn := range fns {
+		if fn.Declaration == nil || fn.Declaration.Name == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "function declaration must have a name")
+		}
+		if fn.Handler == nil {
+			return ierrors.Wrapf(ErrInvalidParameter, "function %q has no handler", fn.Declaration.Name)
+		}
+		if _, ok := registered[fn.Declaration.Name]; ok || seen[fn.Declaration.Name] {
+			return ierrors.Wrapf(ErrInvalidParameter, "function %q is registered more than once", fn.Declaration.Name)
+		}
+		seen[fn.Declaration.Name] = true
+	}
+	return nil
+}
+
+// newFunctionTool bundles the declarations of fns into a single genai.Tool.
+func newFunctionTool(fns []Function) *genai.Tool {
+	decls := make([]*genai.FunctionDeclaration, len(fns))
+	for i, fn := range fns {
+		decls[i] = fn.Declaration
+	}
+	return &genai.Tool{FunctionDeclarations: decls}
+}
+
+// generateWithFunctions calls GenerateContent and, while the model responds with function calls,
+// executes the matching handlers and sends their results back, up to maxRounds round-trips.
+// It returns the final model response together with every function call that was executed.
+// If the client's soft timeout expires, the partial response received so far is returned
+// and partial is true; no further function calls are made.
+func (c *Client) generateWithFunctions(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig, handlers map[string]Function, maxRounds int) (_ *genai.GenerateContentResponse, _ []FunctionCallResult, partial bool, _ error) {
+	var softDeadline time.Time
+	if c.config.SoftTimeout > 0 {
+		softDeadline = time.Now().Add(c.config.SoftTimeout)
+	}
+
+	var results []FunctionCallResult
+	for round := 0; ; round++ {
+		if err := c.rateLimiter.wait(ctx); err != nil {
+			return nil, results, false, err
+		}
+		resp, partial, err := c.generateContent(ctx, model, contents, config, softDeadline)
+		if err != nil || partial || len(handlers) == 0 {
+			return resp, results, partial, err
+		}
+
+		calls := resp.FunctionCalls()
+		if len(calls) == 0 {
+			return resp, results, false, nil
+		}
+		if round >= maxRounds {
+			return nil, results, false, ierrors.Wrapf(ErrFunctionCallLimitExceeded, "model requested function calls after %d rounds", maxRounds)
+		}
+
+		responseParts := make([]*genai.Part, 0, len(calls))
+		for _, call := range calls {
+			result := c.callFunction(ctx, call, handlers)
+			results = append(results, result)
+
+			payload := result.Result
+			if result.Error != "" {
+				payload = map[string]any{"error": result.Error}
+			}
+			part := genai.NewPartFromFunctionResponse(call.Name, payload)
+			part.FunctionResponse.ID = call.ID
+			responseParts = append(responseParts, part)
+		}
+
+		contents = append(contents, resp.Candidates[0].Content, genai.NewContentFromParts(responseParts, genai.RoleUser))
+	}
+}
+
+// callFunction runs the handler registered for call and records the outcome.
+func (c *Client) callFunction(ctx context.Context, call *genai.FunctionCall, handlers map[string]Function) FunctionCallResult {
+	result := FunctionCallResult{
+		ID:   call.ID,
+		Name: call.Name,
+		Args: call.Args,
+	}
+
+	fn, ok := handlers[call.Name]
+	if !ok {
+		result.Error = fmt.Sprintf("unknown function %q", call.Name)
+		return result
+	}
+
+	out, err := fn.Handler(ctx, call.Args)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Result = out
+	return result
+}
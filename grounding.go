@@ -1,6 +1,9 @@
 package search
 
 import (
+	"encoding/json"
+	"strings"
+
 	"google.golang.org/genai"
 )
 
@@ -16,6 +19,196 @@ func newGoogleSearchRetrieverTool() *genai.Tool {
 	}
 }
 
+// withSearchExcludedDomains returns a copy of tools whose Google Search Tool excludes domains
+// from its results.
+func withSearchExcludedDomains(tools []*genai.Tool, domains []string) []*genai.Tool {
+	out := make([]*genai.Tool, len(tools))
+	for i, t := range tools {
+		if t != nil && t.GoogleSearch != nil {
+			search := *t.GoogleSearch
+			search.ExcludeDomains = append(append([]string(nil), search.ExcludeDomains...), domains...)
+			tool := *t
+			tool.GoogleSearch = &search
+			t = &tool
+		}
+		out[i] = t
+	}
+	return out
+}
+
+// withoutSearchTools returns a copy of tools without the web search tools.
+func withoutSearchTools(tools []*genai.Tool) []*genai.Tool {
+	out := make([]*genai.Tool, 0, len(tools))
+	for _, t := range tools {
+		if t != nil && (t.GoogleSearch != nil || t.GoogleSearchRetrieval != nil || t.EnterpriseWebSearch != nil) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// newEnterpriseWebSearchTool creates a new genai.Tool configured for Vertex AI's
+// enterprise web search, a compliance-filtered variant of web grounding.
+func newEnterpriseWebSearchTool() *genai.Tool {
+	return &genai.Tool{
+		EnterpriseWebSearch: &genai.EnterpriseWebSearch{},
+	}
+}
+
+// newURLContextTool creates a new genai.Tool that lets the model read the content
+// of URLs given in the prompt.
+func newURLContextTool() *genai.Tool {
+	return &genai.Tool{
+		URLContext: &genai.URLContext{},
+	}
+}
+
+// extractURLContextMetadata transforms URL context metadata from the SDK into
+// a slice of URLContextMetadata.
+func extractURLContextMetadata(metadata *genai.URLContextMetadata) []URLContextMetadata {
+	if metadata == nil || len(metadata.URLMetadata) == 0 {
+		return nil
+	}
+	out := make([]URLContextMetadata, 0, len(metadata.URLMetadata))
+	for _, m := range metadata.URLMetadata {
+		if m == nil {
+			continue
+		}
+		out = append(out, URLContextMetadata{
+			URL:    m.RetrievedURL,
+			Status: URLRetrievalStatus(m.URLRetrievalStatus),
+		})
+	}
+	return out
+}
+
+// extractSearchSuggestions returns the distinct web search queries the model issued for grounding.
+// If the metadata lists no queries, they are read from the search entry point's SDK blob,
+// a JSON array of [query, url] tuples.
+func extractSearchSuggestions(metadata *genai.GroundingMetadata) []string {
+	if metadata == nil {
+		return nil
+	}
+	queries := metadata.WebSearchQueries
+	if len(queries) == 0 && metadata.SearchEntryPoint != nil && len(metadata.SearchEntryPoint.SDKBlob) > 0 {
+		var tuples [][]string
+		if err := json.Unmarshal(metadata.SearchEntryPoint.SDKBlob, &tuples); err == nil {
+			for _, t := range tuples {
+				if len(t) > 0 {
+					queries = append(queries, t[0])
+				}
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(queries))
+	var suggestions []string
+	for _, q := range queries {
+		q = strings.TrimSpace(q)
+		if q == "" || seen[q] {
+			continue
+		}
+		seen[q] = true
+		suggestions = append(suggestions, q)
+	}
+	return suggestions
+}
+
+// extractSearchEntryPointHTML returns the rendered search suggestion chips, if any.
+func extractSearchEntryPointHTML(metadata *genai.GroundingMetadata) string {
+	if metadata == nil || metadata.SearchEntryPoint == nil {
+		return ""
+	}
+	return metadata.SearchEntryPoint.RenderedContent
+}
+
+// newGroundingDetails converts the SDK grounding metadata into a library-owned GroundingDetails.
+func newGroundingDetails(metadata *genai.GroundingMetadata) *GroundingDetails {
+	if metadata == nil {
+		return nil
+	}
+	details := &GroundingDetails{
+		WebSearchQueries: metadata.WebSearchQueries,
+		RetrievalQueries: metadata.RetrievalQueries,
+	}
+	if metadata.RetrievalMetadata != nil {
+		details.DynamicRetrievalScore = metadata.RetrievalMetadata.GoogleSearchDynamicRetrievalScore
+	}
+	if metadata.SearchEntryPoint != nil {
+		details.SearchEntryPoint = &SearchEntryPoint{
+			RenderedContent: metadata.SearchEntryPoint.RenderedContent,
+			SDKBlob:         metadata.SearchEntryPoint.SDKBlob,
+		}
+	}
+
+	// Nil chunks are kept as empty entries so that support indices stay valid.
+	details.Chunks = make([]GroundingChunk, len(metadata.GroundingChunks))
+	for i, c := range metadata.GroundingChunks {
+		if c == nil {
+			continue
+		}
+		if c.Web != nil {
+			details.Chunks[i].Web = &GroundingChunkWeb{
+				Title:  c.Web.Title,
+				URI:    c.Web.URI,
+				Domain: c.Web.Domain,
+			}
+		}
+		if c.RetrievedContext != nil {
+			details.Chunks[i].RetrievedContext = &GroundingChunkRetrievedContext{
+				Title:        c.RetrievedContext.Title,
+				URI:          c.RetrievedContext.URI,
+				Text:         c.RetrievedContext.Text,
+				DocumentName: c.RetrievedContext.DocumentName,
+			}
+		}
+	}
+
+	for _, s := range metadata.GroundingSupports {
+		if s == nil {
+			continue
+		}
+		support := GroundingSupport{
+			ConfidenceScores: s.ConfidenceScores,
+		}
+		if s.Segment != nil {
+			support.Segment = &TextSegment{
+				PartIndex:  int(s.Segment.PartIndex),
+				StartIndex: int(s.Segment.StartIndex),
+				EndIndex:   int(s.Segment.EndIndex),
+				Text:       s.Segment.Text,
+			}
+		}
+		if len(s.GroundingChunkIndices) > 0 {
+			support.ChunkIndices = make([]int, len(s.GroundingChunkIndices))
+			for i, idx := range s.GroundingChunkIndices {
+				support.ChunkIndices[i] = int(idx)
+			}
+		}
+		details.Supports = append(details.Supports, support)
+	}
+	return details
+}
+
+// ExtractGrounding converts the grounding metadata of a raw SDK candidate into grounding
+// attributions, using the same extraction as the client. It is useful when processing
+// RawResponse, Candidates, or responses obtained from the genai SDK directly.
+// URLs are returned as given by the API (no redirect resolution), and IDs are assigned as by the
+// client.
+// A nil candidate or one without grounding metadata yields an empty slice.
+func ExtractGrounding(candidate *genai.Candidate) ([]GroundingAttribution, error) {
+	if candidate == nil {
+		return []GroundingAttribution{}, nil
+	}
+	attrs, err := extractGroundingMetadata(candidate.GroundingMetadata)
+	if err != nil {
+		return nil, err
+	}
+	assignAttributionIDs(attrs)
+	return attrs, nil
+}
+
 // extractGroundingMetadata transforms grounding metadata from the SDK (*genai.GroundingMetadata)
 // into a slice of GroundingAttribution.
 func extractGroundingMetadata(metadata *genai.GroundingMetadata) ([]GroundingAttribution, error) {
@@ -68,8 +261,8 @@ func extractGroundingMetadata(metadata *genai.GroundingMetadata) ([]GroundingAtt
 		segment := s.Segment
 		confidenceScore := float32(0.0)
 
-		// If ConfidenceScores are available, use the first one for this segment.
-		// Adjust this logic if a more specific mapping is required.
+		// ConfidenceScores normally parallels GroundingChunkIndices (see below).
+		// Otherwise, if any are available, use the first one for this segment.
 		if len(s.ConfidenceScores) > 0 {
 			confidenceScore = s.ConfidenceScores[0]
 		}
@@ -83,10 +276,14 @@ func extractGroundingMetadata(metadata *genai.GroundingMetadata) ([]GroundingAtt
 		}
 
 		// Link this segment to all chunks referenced by this support.
-		for _, chunkIndex32 := range s.GroundingChunkIndices {
+		for j, chunkIndex32 := range s.GroundingChunkIndices {
 			chunkIndex := int(chunkIndex32)
 			if chunkIndex >= 0 && chunkIndex < numChunks {
-				appAttributions[chunkIndex].Segments = append(appAttributions[chunkIndex].Segments, appSegment)
+				linked := appSegment
+				if len(s.ConfidenceScores) == len(s.GroundingChunkIndices) {
+					linked.ConfidenceScore = s.ConfidenceScores[j]
+				}
+				appAttributions[chunkIndex].Segments = append(appAttributions[chunkIndex].Segments, linked)
 			} else {
 				// Handle or log invalid chunk index if necessary
 				// return nil, fmt.Errorf("invalid chunk index %d in GroundingSupport", chunkIndex)
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"time"
+)
+
+// RequestEvent describes a generation call as it starts.
+type RequestEvent struct {
+	// Params are the parameters the call was made with, with prompts redacted if a prompt
+	// redactor is configured (see WithPromptRedactor). They must not be modified.
+	Params *GenerationParams
+
+	// Start is when the call started.
+	Start time.Time
+}
+
+// ResponseEvent describes a generation call that succeeded.
+type ResponseEvent struct {
+	// Params are the parameters the call was made with, with prompts redacted if a prompt
+	// redactor is configured (see WithPromptRedactor). They must not be modified.
+	Params *GenerationParams
+
+	// Response is the response returned to the caller. It must not be modified.
+	Response *Response
+
+	// Duration is the wall-clock time of the call, including interceptors.
+	Duration time.Duration
+}
+
+// ErrorEvent describes a generation call that failed.
+type ErrorEvent struct {
+	// Params are the parameters the call was made with, with prompts redacted if a prompt
+	// redactor is configured (see WithPromptRedactor). They must not be modified.
+	Params *GenerationParams
+
+	// Err is the error returned to the caller.
+	Err error
+
+	// ErrorClass classifies Err (see ClassifyError).
+	ErrorClass ErrorClass
+
+	// Duration is the wall-clock time of the call, including interceptors.
+	Duration time.Duration
+}
+
+// RequestHook is called when a generation call starts (see WithOnRequest).
+type RequestHook func(ctx context.Context, e RequestEvent)
+
+// ResponseHook is called when a generation call succeeds (see WithOnResponse).
+type ResponseHook func(ctx context.Context, e ResponseEvent)
+
+// ErrorHook is called when a generation call fails (see WithOnError).
+type ErrorHook func(ctx context.Context, e ErrorEvent)
+
+// generateWithHooks calls the interceptor chain for params, notifying the configured hooks.
+func (c *Client) generateWithHooks(ctx context.Context, params *GenerationParams) (*Response, error) {
+	start := time.Now()
+	eventParams := params
+	if len(c.config.OnRequest)+len(c.config.OnResponse)+len(c.config.OnError) > 0 {
+		eventParams = c.redactedParams(params)
+	}
+	for _, hook := range c.config.OnRequest {
+		hook(ctx, RequestEvent{Params: eventParams, Start: start})
+	}
+
+	resp, err := c.handler(ctx, params)
+	err = c.closedError(err)
+
+	duration := time.Since(start)
+	if err != nil {
+		e := ErrorEvent{Params: eventParams, Err: err, ErrorClass: ClassifyError(err), Duration: duration}
+		for _, hook := range c.config.OnError {
+			hook(ctx, e)
+		}
+		return resp, err
+	}
+	for _, hook := range c.config.OnResponse {
+		hook(ctx, ResponseEvent{Params: eventParams, Response: resp, Duration: duration})
+	}
+	return resp, nil
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// groundingRedirectHost is the host serving the grounding service's redirect URLs.
+// Requests to it are not subject to per-host limits, since they never reach the cited origin;
+// fetches of sources resolve their redirect URLs first (see Client.sourceURL).
+const groundingRedirectHost = "vertexaisearch.cloud.google.com"
+
+// hostLimiter bounds the number of concurrent outbound requests per host.
+// It is shared by all URL resolution and fetching performed by a Client.
+type hostLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// newHostLimiter creates a hostLimiter allowing limit concurrent requests per host.
+// A limit of zero or less disables limiting.
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+// acquire blocks until a request slot for the host of rawURL is available or ctx is done.
+// The returned release function must be called once the request has finished.
+func (l *hostLimiter) acquire(ctx context.Context, rawURL string) (release func(), err error) {
+	host := hostOf(rawURL)
+	if l == nil || l.limit <= 0 || host == "" || host == groundingRedirectHost {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	sem, ok := l.slots[host]
+	if !ok {
+		sem = make(chan struct{}, l.limit)
+		l.slots[host] = sem
+	}
+	l.mu.Unlock()
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// hostOf returns the lower-cased host name of rawURL, or an empty string if it cannot be parsed.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// sourceURL returns the URL to fetch the source at rawURL from: rawURL itself, or its origin URL
+// if it is a grounding redirect URL. Fetching the origin directly, rather than following the
+// redirect, subjects the fetch to the per-host limit of the origin.
+func (c *Client) sourceURL(ctx context.Context, rawURL string) (string, error) {
+	if hostOf(rawURL) != groundingRedirectHost {
+		return rawURL, nil
+	}
+	origin, _, err := c.resolveURL(ctx, rawURL)
+	if err != nil {
+		return "", err
+	}
+	if origin == "" {
+		return rawURL, nil
+	}
+	return origin, nil
+}
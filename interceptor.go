@@ -0,0 +1,27 @@
+package search
+
+import (
+	"context"
+)
+
+// GenerateFunc performs a grounded generation request. It has the signature of
+// Client.GenerateGroundedContentWithParams.
+type GenerateFunc func(ctx context.Context, params *GenerationParams) (*Response, error)
+
+// Interceptor wraps every generation call made through the client. It may inspect or
+// rewrite params, short-circuit with its own response (e.g., from a cache), or post-process
+// the response and error returned by next. Calling next continues the chain.
+type Interceptor func(ctx context.Context, params *GenerationParams, next GenerateFunc) (*Response, error)
+
+// chainInterceptors wraps final with interceptors. The first interceptor is the outermost,
+// so it sees the request first and the response last.
+func chainInterceptors(interceptors []Interceptor, final GenerateFunc) GenerateFunc {
+	h := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], h
+		h = func(ctx context.Context, params *GenerationParams) (*Response, error) {
+			return interceptor(ctx, params, next)
+		}
+	}
+	return h
+}
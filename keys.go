@@ -0,0 +1,89 @@
+package search
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// apiKeyQuotaCooldown is how long an API key is skipped after it hit a quota error, unless the
+// API specified a retry delay.
+const apiKeyQuotaCooldown = time.Minute
+
+// keyPool rotates generation requests over SDK clients created for different API keys,
+// round-robin. Keys that hit a quota error are demoted, i.e., skipped until their cooldown ends.
+type keyPool struct {
+	clients []*genai.Client
+
+	mu           sync.Mutex
+	next         int
+	demotedUntil []time.Time
+}
+
+// newKeyPool creates a keyPool, or returns nil if there is only one client to rotate over.
+func newKeyPool(clients []*genai.Client) *keyPool {
+	if len(clients) < 2 {
+		return nil
+	}
+	return &keyPool{clients: clients, demotedUntil: make([]time.Time, len(clients))}
+}
+
+// pick returns the index and client of the next key that is not demoted. If every key is
+// demoted, the one whose cooldown ends first is returned.
+func (p *keyPool) pick() (int, *genai.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	earliest := -1
+	for range p.clients {
+		i := p.next
+		p.next = (p.next + 1) % len(p.clients)
+		if !now.Before(p.demotedUntil[i]) {
+			return i, p.clients[i]
+		}
+		if earliest < 0 || p.demotedUntil[i].Before(p.demotedUntil[earliest]) {
+			earliest = i
+		}
+	}
+	return earliest, p.clients[earliest]
+}
+
+// record demotes the key at index i if err is a quota error, for the delay the API asked for or
+// apiKeyQuotaCooldown if it gave none. It returns the demotion, or zero if the key was not demoted.
+func (p *keyPool) record(i int, err error) time.Duration {
+	if err == nil {
+		return 0
+	}
+	apiErr := newAPIErrorFromSDK(err, "")
+	if !IsQuotaError(apiErr) {
+		return 0
+	}
+	cooldown := apiErr.RetryAfter
+	if cooldown <= 0 {
+		cooldown = apiKeyQuotaCooldown
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.demotedUntil[i] = time.Now().Add(cooldown)
+	return cooldown
+}
+
+// generationClient returns the SDK client to send the next generation request with, and a
+// function that must be called with the request's error. Without key rotation, it always
+// returns the client's own SDK client.
+func (c *Client) generationClient() (*genai.Client, func(error)) {
+	if c.keys == nil {
+		return c.genaiClient, func(error) {}
+	}
+	i, client := c.keys.pick()
+	return client, func(err error) {
+		if cooldown := c.keys.record(i, err); cooldown > 0 {
+			c.logger.Warn("gemini: API key hit its quota and is skipped for a while",
+				slog.Int("key_index", i),
+				slog.Duration("cooldown", cooldown),
+			)
+		}
+	}
+}
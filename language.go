@@ -0,0 +1,209 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// minLanguageDetectionLetters is the number of letters below which the language of a text is
+// not detected.
+const minLanguageDetectionLetters = 20
+
+// languageNames are the languages whose answers can be checked, by ISO 639-1 code.
+var languageNames = map[string]string{
+	"ar": "Arabic", "de": "German", "el": "Greek", "en": "English", "es": "Spanish",
+	"fr": "French", "he": "Hebrew", "hi": "Hindi", "it": "Italian", "ja": "Japanese",
+	"ko": "Korean", "nl": "Dutch", "pt": "Portuguese", "ru": "Russian", "th": "Thai",
+	"uk": "Ukrainian", "zh": "Chinese",
+}
+
+// latinStopwords are frequent words that tell apart languages written in the Latin script.
+var latinStopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "in", "that", "it", "with", "for", "was", "this", "as", "on"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "en", "es", "por", "para", "una", "con", "del", "se"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "un", "une", "que", "dans", "pour", "du", "sur", "au"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "den", "von", "zu", "ein", "eine", "auf", "für", "sich"},
+	"it": {"il", "la", "di", "che", "e", "è", "per", "una", "con", "del", "della", "sono", "gli", "non", "le"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "é", "do", "da", "em", "para", "com", "uma", "não"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "op", "te", "in", "niet", "voor", "met", "zijn", "die"},
+}
+
+// cyrillicLanguages are the checked languages written in Cyrillic, which are not told apart.
+var cyrillicLanguages = map[string]bool{"ru": true, "uk": true}
+
+// languageTagPattern matches a BCP 47 language tag such as "en", "ja-JP", or "zh-Hant-TW".
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// nonProsePattern matches URLs and citation markers, which are left out of language detection.
+var nonProsePattern = regexp.MustCompile(`https?://\S+|\[\d+(,\s*\d+)*\]`)
+
+// validateResponseLanguage checks that lang is a language tag.
+func validateResponseLanguage(lang string) error {
+	if !languageTagPattern.MatchString(lang) {
+		return ierrors.Wrapf(ErrInvalidParameter, "response language must be a language tag such as \"en\" or \"ja-JP\", got %q", lang)
+	}
+	return nil
+}
+
+// baseLanguage returns the lowercase primary subtag of a language tag, e.g., "pt" for "pt-BR".
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return strings.ToLower(base)
+}
+
+// languageName returns the English name of the language with tag, or the tag itself if unknown.
+func languageName(tag string) string {
+	if name, ok := languageNames[baseLanguage(tag)]; ok {
+		return fmt.Sprintf("%s (%s)", name, tag)
+	}
+	return tag
+}
+
+// detectLanguage returns the ISO 639-1 code of the language text is written in, or an empty
+// string if it is too short or not one of the languages in languageNames. Cyrillic text is
+// reported as "ru".
+func detectLanguage(text string) string {
+	text = nonProsePattern.ReplaceAllString(text, " ")
+
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			counts["kana"]++
+		case unicode.Is(unicode.Han, r):
+			counts["han"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		case unicode.Is(unicode.Latin, r):
+			counts["latin"]++
+		}
+	}
+	if letters < minLanguageDetectionLetters {
+		return ""
+	}
+
+	// Japanese mixes kana with Han characters; Chinese uses no kana.
+	if cjk := counts["kana"] + counts["han"]; cjk > counts["latin"] {
+		if counts["kana"]*10 >= cjk {
+			return "ja"
+		}
+		return "zh"
+	}
+	script, best := "", 0
+	for s, n := range counts {
+		if s != "kana" && s != "han" && n > best {
+			script, best = s, n
+		}
+	}
+	if script != "latin" {
+		return script
+	}
+	return detectLatinLanguage(text)
+}
+
+// detectLatinLanguage returns the language of Latin-script text by its most frequent stopwords,
+// or an empty string if no language clearly prevails.
+func detectLatinLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	freq := make(map[string]int, len(words))
+	for _, w := range words {
+		freq[w]++
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for lang, stopwords := range latinStopwords {
+		score := 0
+		for _, w := range stopwords {
+			score += freq[w]
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, secondScore = lang, score, bestScore
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+	if bestScore < 3 || bestScore*2 < secondScore*3 {
+		return ""
+	}
+	return best
+}
+
+// languageMatches reports whether text is written in the language with tag. It reports true
+// when the language cannot be checked: an unsupported language, or too little text.
+func languageMatches(tag, text string) (detected string, ok bool) {
+	want := baseLanguage(tag)
+	if _, supported := languageNames[want]; !supported {
+		return "", true
+	}
+	detected = detectLanguage(text)
+	switch {
+	case detected == "" || detected == want:
+		return detected, true
+	case cyrillicLanguages[want] && detected == "ru":
+		return want, true
+	}
+	return detected, false
+}
+
+// enforceResponseLanguage checks that resp is written in params.ResponseLanguage. If it is not,
+// the request is retried once with a stronger instruction, and the retry is used if it matches.
+// The outcome is recorded in Response.DetectedLanguage and Response.LanguageMismatch and the
+// retry is appended to attempts. A failed retry is not an error; the original response is kept.
+func (c *Client) enforceResponseLanguage(ctx context.Context, params *GenerationParams, resp *Response, attempts *[]Attempt) *Response {
+	detected, ok := languageMatches(params.ResponseLanguage, resp.GeneratedText)
+	resp.DetectedLanguage = detected
+	if ok {
+		return resp
+	}
+
+	retryParams := *params
+	guidance := fmt.Sprintf("The answer must be written in %s even though the sources may be in another language. "+
+		"Translate the relevant information instead of answering in the language of the sources.", languageName(params.ResponseLanguage))
+	if strings.TrimSpace(retryParams.SearchGuidance) != "" {
+		guidance = retryParams.SearchGuidance + "\n" + guidance
+	}
+	retryParams.SearchGuidance = guidance
+
+	c.logger.InfoContext(ctx, "gemini: answer not in requested language, retrying",
+		slog.String("requested", params.ResponseLanguage),
+		slog.String("detected", detected),
+	)
+	retried, err := c.generateAttempt(ctx, &retryParams, AttemptReasonLanguage, attempts)
+	if err == nil {
+		if retriedDetected, retriedOK := languageMatches(params.ResponseLanguage, retried.GeneratedText); retriedOK {
+			retried.DetectedLanguage = retriedDetected
+			if resp.DomainDiversityMet != nil {
+				retried.DomainDiversityMet = boolPtr(len(retried.Domains()) >= c.config.MinDistinctDomains)
+			}
+			return retried
+		}
+	}
+	resp.LanguageMismatch = true
+	return resp
+}
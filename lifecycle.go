@@ -0,0 +1,68 @@
+package search
+
+import (
+	"context"
+	"errors"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// Close releases the resources held by the client. In-flight requests, including those
+// waiting on the rate limiter or resolving URLs, are canceled, idle HTTP connections are
+// closed, and every subsequent call returns ErrClientClosed.
+// Close is safe to call more than once and from multiple goroutines; it always returns nil.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeCancel()
+		if c.httpClient != nil {
+			c.httpClient.CloseIdleConnections()
+		}
+		if c.resolverClient != nil && c.resolverClient != c.httpClient {
+			c.resolverClient.CloseIdleConnections()
+		}
+		c.logger.Debug("gemini: client closed")
+	})
+	return nil
+}
+
+// Ping verifies the API key, connectivity, and the client's default model with a lightweight
+// model lookup, so that services can fail fast at startup or in health checks instead of on the
+// first user query. API failures are returned as an *APIError, e.g., one for which
+// IsAuthenticationError reports true if the API key is invalid; use ClassifyError to tell them apart.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel, err := c.bindToClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if _, err := c.genaiClient.Models.Get(ctx, normalizeModelName(c.defaultModel, c.config.Backend), nil); err != nil {
+		if err := c.closedError(err); errors.Is(err, ErrClientClosed) || ctx.Err() != nil {
+			return err
+		}
+		return newAPIErrorFromSDK(err, "ping failed")
+	}
+	return nil
+}
+
+// bindToClient returns a context that is canceled when ctx is done or the client is closed.
+// It returns ErrClientClosed if the client is already closed.
+func (c *Client) bindToClient(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if c.closeCtx.Err() != nil {
+		return nil, nil, ErrClientClosed
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.closeCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}, nil
+}
+
+// closedError replaces err with ErrClientClosed if the call failed because the client was closed.
+func (c *Client) closedError(err error) error {
+	if err != nil && c.closeCtx.Err() != nil && errors.Is(err, context.Canceled) {
+		return ierrors.Wrap(ErrClientClosed, "request canceled")
+	}
+	return err
+}
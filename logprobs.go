@@ -0,0 +1,74 @@
+package search
+
+import (
+	"google.golang.org/genai"
+)
+
+// maxLogprobs is the maximum number of top candidate tokens the API returns per decoding step.
+const maxLogprobs = 20
+
+// Logprobs holds the log probabilities of the tokens generated for a candidate, as requested
+// with GenerationParams.ResponseLogprobs.
+type Logprobs struct {
+	// AvgLogprob is the average log probability of the generated tokens. Values closer to zero
+	// indicate the model was more confident in its output.
+	AvgLogprob float64 `json:"avg_logprob"`
+
+	// Chosen lists the token chosen at each decoding step, in order.
+	Chosen []TokenLogprob `json:"chosen,omitempty"`
+
+	// Top lists, for each decoding step, the most likely tokens sorted by log probability in
+	// descending order. It is only populated if GenerationParams.Logprobs is set.
+	Top [][]TokenLogprob `json:"top,omitempty"`
+}
+
+// TokenLogprob is the log probability of a single token.
+type TokenLogprob struct {
+	// Token is the token's string value.
+	Token string `json:"token"`
+
+	// TokenID is the token's ID in the model's vocabulary.
+	TokenID int32 `json:"token_id,omitempty"`
+
+	// Logprob is the log probability of the token.
+	Logprob float32 `json:"logprob"`
+}
+
+// newLogprobs converts the logprobs of an SDK candidate into a library-owned Logprobs.
+// It returns nil if the candidate carries no logprobs.
+func newLogprobs(candidate *genai.Candidate) *Logprobs {
+	if candidate == nil || candidate.LogprobsResult == nil {
+		return nil
+	}
+	lp := &Logprobs{
+		AvgLogprob: candidate.AvgLogprobs,
+		Chosen:     newTokenLogprobs(candidate.LogprobsResult.ChosenCandidates),
+	}
+	for _, step := range candidate.LogprobsResult.TopCandidates {
+		if step == nil {
+			lp.Top = append(lp.Top, nil)
+			continue
+		}
+		lp.Top = append(lp.Top, newTokenLogprobs(step.Candidates))
+	}
+	return lp
+}
+
+// newTokenLogprobs converts SDK logprob candidates into TokenLogprob values.
+func newTokenLogprobs(candidates []*genai.LogprobsResultCandidate) []TokenLogprob {
+	if len(candidates) == 0 {
+		return nil
+	}
+	out := make([]TokenLogprob, 0, len(candidates))
+	for _, c := range candidates {
+		if c == nil {
+			continue
+		}
+		out = append(out, TokenLogprob{
+			Token:   c.Token,
+			TokenID: c.TokenID,
+			Logprob: c.LogProbability,
+		})
+	}
+	return out
+}
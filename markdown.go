@@ -0,0 +1,96 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ToMarkdown renders the generated text as Markdown with footnote-style citations.
+// A footnote reference ([^n]) is inserted at the end of every grounded segment, where n is the
+// 1-based position of the cited attribution in GroundingAttributions, and a footnote definition
+// with the source's title, domain, and URL is appended for every attribution.
+// Segments whose offsets do not fall on a character boundary of the text are not annotated.
+func (r *Response) ToMarkdown() string {
+	if r == nil {
+		return ""
+	}
+	text := r.GeneratedText
+
+	// Collect the footnotes to insert at each segment end offset.
+	refs := make(map[int][]int)
+	for i, attr := range r.GroundingAttributions {
+		for _, seg := range attr.Segments {
+			end := seg.EndIndex
+			if end <= 0 || end > len(text) || (end < len(text) && !utf8.RuneStart(text[end])) {
+				continue
+			}
+			refs[end] = appendUnique(refs[end], i+1)
+		}
+	}
+	offsets := make([]int, 0, len(refs))
+	for off := range refs {
+		offsets = append(offsets, off)
+	}
+	sort.Ints(offsets)
+
+	var b strings.Builder
+	prev := 0
+	for _, off := range offsets {
+		b.WriteString(text[prev:off])
+		notes := refs[off]
+		sort.Ints(notes)
+		for _, n := range notes {
+			fmt.Fprintf(&b, "[^%d]", n)
+		}
+		prev = off
+	}
+	b.WriteString(text[prev:])
+
+	if len(r.GroundingAttributions) > 0 {
+		b.WriteString("\n\n")
+		for i, attr := range r.GroundingAttributions {
+			fmt.Fprintf(&b, "[^%d]: %s\n", i+1, markdownSource(attr))
+		}
+	}
+	return b.String()
+}
+
+// markdownSource renders an attribution as a Markdown link followed by its domain.
+func markdownSource(attr GroundingAttribution) string {
+	title := strings.TrimSpace(attr.Title)
+	domain := attributionDomain(attr)
+	if title == "" {
+		title = domain
+	}
+	if title == "" {
+		title = attr.URL
+	}
+
+	var s string
+	if attr.URL != "" {
+		s = fmt.Sprintf("[%s](<%s>)", escapeMarkdownLinkText(title), attr.URL)
+	} else {
+		s = escapeMarkdownLinkText(title)
+	}
+	if domain != "" && domain != title {
+		s += " — " + domain
+	}
+	return s
+}
+
+// escapeMarkdownLinkText escapes the characters that would end or break Markdown link text.
+func escapeMarkdownLinkText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "\n", " ").Replace(s)
+}
+
+// appendUnique appends n to s unless it is already present.
+func appendUnique(s []int, n int) []int {
+	for _, v := range s {
+		if v == n {
+			return s
+		}
+	}
+	return append(s, n)
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"context"
+	"time"
+)
+
+// RequestMetrics describes a single completed generation request.
+type RequestMetrics struct {
+	// Model is the model the request was sent to.
+	Model string
+
+	// Duration is the wall-clock time of the request, including function-call
+	// round-trips and URL resolution.
+	Duration time.Duration
+
+	// PromptTokens, OutputTokens, and TotalTokens are the token counts reported by the API.
+	// They are zero if the request failed or the API did not report usage.
+	PromptTokens int32
+	OutputTokens int32
+	TotalTokens  int32
+
+	// Attributions is the number of grounding attributions in the response.
+	Attributions int
+
+	// ErrorClass classifies the failure, or is empty if the request succeeded.
+	ErrorClass ErrorClass
+}
+
+// URLResolutionMetrics describes one batch of grounding URL resolutions.
+type URLResolutionMetrics struct {
+	// Resolved is the number of URLs resolved successfully.
+	Resolved int
+
+	// Failed is the number of URLs that could not be resolved, including those
+	// left unresolved because the resolution deadline was reached.
+	Failed int
+
+	// CacheHits is the number of resolved URLs that were served from the URL resolution cache
+	// (see WithURLResolutionCache). They are included in Resolved.
+	CacheHits int
+
+	// Duration is the wall-clock time spent resolving the batch.
+	Duration time.Duration
+}
+
+// MetricsRecorder receives metrics from the client. Implementations can export them to
+// Prometheus, statsd, or any other monitoring system. Methods are called synchronously
+// on the request path and must be safe for concurrent use, so they should not block.
+type MetricsRecorder interface {
+	// RecordRequest is called once per generation request, successful or not. Query moderation
+	// requests (see WithQueryModeration) are recorded separately, under the moderation model.
+	RecordRequest(ctx context.Context, m RequestMetrics)
+
+	// RecordURLResolution is called once per response whose grounding URLs were resolved.
+	RecordURLResolution(ctx context.Context, m URLResolutionMetrics)
+}
+
+// noopMetricsRecorder is the MetricsRecorder used when none is configured.
+type noopMetricsRecorder struct{}
+
+func (noopMetricsRecorder) RecordRequest(context.Context, RequestMetrics)             {}
+func (noopMetricsRecorder) RecordURLResolution(context.Context, URLResolutionMetrics) {}
+
+// newRequestMetrics builds the RequestMetrics for a finished request.
+func newRequestMetrics(model string, duration time.Duration, resp *Response, err error) RequestMetrics {
+	m := RequestMetrics{
+		Model:      model,
+		Duration:   duration,
+		ErrorClass: ClassifyError(err),
+	}
+	if resp != nil {
+		m.Attributions = len(resp.GroundingAttributions)
+		if prompt, output, total, ok := resp.tokenCounts(); ok {
+			m.PromptTokens, m.OutputTokens, m.TotalTokens = prompt, output, total
+		}
+	}
+	return m
+}
@@ -0,0 +1,226 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"google.golang.org/genai"
+)
+
+// Resource name prefixes accepted by the genai SDK when a model is given by its full name.
+const (
+	modelResourcePrefix      = "models/"
+	tunedModelResourcePrefix = "tunedModels/"
+	projectResourcePrefix    = "projects/"
+	publisherResourcePrefix  = "publishers/"
+)
+
+// validateModelName checks that name is either a plain model ID (e.g., "gemini-3.5-flash")
+// or a fully qualified model resource name the SDK can route.
+func validateModelName(name string) error {
+	if name == "" {
+		return ErrInvalidModelName
+	}
+	if strings.ContainsAny(name, "?& \t\n") || strings.Contains(name, "..") {
+		return ierrors.Wrapf(ErrInvalidModelName, "model name %q contains invalid characters", name)
+	}
+	if !strings.Contains(name, "/") {
+		return nil
+	}
+	if strings.HasSuffix(name, "/") || strings.Contains(name, "//") {
+		return ierrors.Wrapf(ErrInvalidModelName, "model name %q has an empty path segment", name)
+	}
+	for _, prefix := range []string{modelResourcePrefix, tunedModelResourcePrefix, projectResourcePrefix, publisherResourcePrefix} {
+		if strings.HasPrefix(name, prefix) {
+			return nil
+		}
+	}
+	return ierrors.Wrapf(ErrInvalidModelName, "model name %q is not a recognized model resource name", name)
+}
+
+// isTunedModelName reports whether name refers to a fine-tuned model, either as
+// "tunedModels/{id}", "projects/{project}/tunedModels/{id}", or a Vertex AI endpoint
+// ("projects/{project}/locations/{location}/endpoints/{id}").
+func isTunedModelName(name string) bool {
+	segments := strings.Split(name, "/")
+	switch {
+	case len(segments) == 2 && segments[0] == "tunedModels":
+		return segments[1] != ""
+	case len(segments) == 4 && segments[0] == "projects" && segments[2] == "tunedModels":
+		return segments[1] != "" && segments[3] != ""
+	case len(segments) == 6 && segments[0] == "projects" && segments[2] == "locations" && segments[4] == "endpoints":
+		return segments[1] != "" && segments[3] != "" && segments[5] != ""
+	}
+	return false
+}
+
+// normalizeModelName rewrites a model name into the form expected by the
+// GenerateContent endpoint of backend. The Gemini API addresses tuned models as
+// "tunedModels/{id}", so a project-qualified tuned model name is shortened accordingly.
+// All other names are returned unchanged and routed by the SDK.
+func normalizeModelName(name string, backend Backend) string {
+	if backend == BackendVertexAI {
+		return name
+	}
+	segments := strings.Split(name, "/")
+	if len(segments) == 4 && segments[0] == "projects" && segments[2] == "tunedModels" {
+		return tunedModelResourcePrefix + segments[3]
+	}
+	return name
+}
+
+// ModelInfo describes a model available to the client.
+type ModelInfo struct {
+	// Name is the resource name of the model, e.g., "models/gemini-3.5-flash".
+	Name string `json:"name"`
+
+	// DisplayName is the human-readable name of the model.
+	DisplayName string `json:"display_name,omitempty"`
+
+	// Description is a short description of the model.
+	Description string `json:"description,omitempty"`
+
+	// InputTokenLimit is the maximum number of input tokens the model accepts, or 0 if unknown.
+	InputTokenLimit int32 `json:"input_token_limit,omitempty"`
+
+	// OutputTokenLimit is the maximum number of tokens the model can generate, or 0 if unknown.
+	OutputTokenLimit int32 `json:"output_token_limit,omitempty"`
+
+	// SupportedActions lists the API methods the model supports, e.g., "generateContent".
+	// The Vertex AI backend does not report them, in which case it is empty.
+	SupportedActions []string `json:"supported_actions,omitempty"`
+
+	// Thinking reports whether the model supports thinking.
+	Thinking bool `json:"thinking,omitempty"`
+
+	// Grounding reports whether the model supports grounded generation with the Google Search
+	// tool. The API does not report tool support, so it is inferred from the model ID and may be
+	// wrong for newly released or specialized models.
+	Grounding bool `json:"grounding"`
+}
+
+// newModelInfo converts an SDK model into a ModelInfo.
+func newModelInfo(m *genai.Model) ModelInfo {
+	info := ModelInfo{
+		Name:             m.Name,
+		DisplayName:      m.DisplayName,
+		Description:      m.Description,
+		InputTokenLimit:  m.InputTokenLimit,
+		OutputTokenLimit: m.OutputTokenLimit,
+		SupportedActions: m.SupportedActions,
+		Thinking:         m.Thinking,
+	}
+	info.Grounding = info.supportsGrounding()
+	return info
+}
+
+// generateContentAction is the supported action of models that can generate content.
+const generateContentAction = "generateContent"
+
+// searchIncapableModelMarkers are fragments of the IDs of models that do not support the
+// Google Search tool: first-generation Gemini models and specialized models.
+var searchIncapableModelMarkers = []string{
+	"gemini-1.", "embedding", "imagen", "veo", "tts", "image", "native-audio", "aqa", "gemma",
+	"gemini-2.0-flash-lite",
+}
+
+// supportsAction reports whether m supports action. Models that do not report their supported
+// actions are assumed to support it.
+func (m ModelInfo) supportsAction(action string) bool {
+	if len(m.SupportedActions) == 0 {
+		return true
+	}
+	for _, a := range m.SupportedActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// supportsGrounding reports whether m can be used for grounded generation with the Google
+// Search tool. The API does not report tool support, so it is inferred from the model ID.
+func (m ModelInfo) supportsGrounding() bool {
+	if !m.supportsAction(generateContentAction) {
+		return false
+	}
+	id := m.Name[strings.LastIndex(m.Name, "/")+1:]
+	if !strings.HasPrefix(id, "gemini-") {
+		return false
+	}
+	for _, marker := range searchIncapableModelMarkers {
+		if strings.Contains(id, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+// ListModels returns the models available to the client with their token limits and
+// supported actions.
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	ctx, cancel, err := c.bindToClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	var models []ModelInfo
+	for m, err := range c.genaiClient.Models.All(ctx) {
+		if err != nil {
+			return nil, ierrors.Wrapf(c.closedError(err), "failed to list models")
+		}
+		if m == nil {
+			continue
+		}
+		models = append(models, newModelInfo(m))
+	}
+
+	if len(models) == 0 {
+		return nil, errors.New("no models available")
+	}
+
+	return models, nil
+}
+
+// GetModelInfo returns the token limits and supported features of the model with the given name,
+// e.g., "gemini-3.5-flash" or "models/gemini-3.5-flash", so that callers can check MaxOutputTokens
+// and prompt size before sending a request.
+func (c *Client) GetModelInfo(ctx context.Context, name string) (*ModelInfo, error) {
+	if err := validateModelName(name); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel, err := c.bindToClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	m, err := c.genaiClient.Models.Get(ctx, normalizeModelName(name, c.config.Backend), nil)
+	if err != nil {
+		return nil, ierrors.Wrapf(c.closedError(err), "failed to get model %s", name)
+	}
+	info := newModelInfo(m)
+	return &info, nil
+}
+
+// ListGroundingCapableModels returns the available models that support grounded generation
+// with the Google Search tool (see ModelInfo.Grounding), so that a configured model name can be
+// validated up front.
+func (c *Client) ListGroundingCapableModels(ctx context.Context) ([]ModelInfo, error) {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var capable []ModelInfo
+	for _, m := range models {
+		if m.Grounding {
+			capable = append(capable, m)
+		}
+	}
+	return capable, nil
+}
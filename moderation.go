@@ -0,0 +1,163 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"google.golang.org/genai"
+)
+
+// QueryRejectedError is returned when query moderation rejects a prompt before the
+// grounded request is sent. It matches ErrQueryRejected with errors.Is.
+type QueryRejectedError struct {
+	// Categories lists the harm categories the query was classified under.
+	Categories []HarmCategory
+
+	// Reason is a short explanation from the moderation model, if provided.
+	Reason string
+}
+
+// Error implements the error interface for QueryRejectedError.
+func (e *QueryRejectedError) Error() string {
+	cats := make([]string, len(e.Categories))
+	for i, c := range e.Categories {
+		cats[i] = string(c)
+	}
+	msg := fmt.Sprintf("%v (categories: %s)", ErrQueryRejected, strings.Join(cats, ", "))
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// Unwrap returns ErrQueryRejected, allowing errors.Is(err, ErrQueryRejected).
+func (e *QueryRejectedError) Unwrap() error {
+	return ErrQueryRejected
+}
+
+// moderationVerdict is the structured output of the moderation model.
+type moderationVerdict struct {
+	Allowed    bool     `json:"allowed"`
+	Categories []string `json:"categories"`
+	Reason     string   `json:"reason"`
+}
+
+// moderationCategories are the harm categories the moderation model may report.
+var moderationCategories = []HarmCategory{
+	HarmCategoryHarassment,
+	HarmCategoryHateSpeech,
+	HarmCategorySexuallyExplicit,
+	HarmCategoryDangerousContent,
+}
+
+// moderateQuery classifies the query of params, written or spoken in its audio clip, with the
+// cheap moderation model and returns a *QueryRejectedError if it is disallowed. Errors from the
+// moderation call itself are returned as-is, so the grounded request is not sent when
+// moderation cannot complete. The call is bounded by the request timeout and reported to the
+// MetricsRecorder like other requests.
+func (c *Client) moderateQuery(ctx context.Context, params *GenerationParams) error {
+	enum := make([]string, len(moderationCategories))
+	for i, cat := range moderationCategories {
+		enum[i] = string(cat)
+	}
+	temperature := float32(0)
+	config := &genai.GenerateContentConfig{
+		Temperature:      &temperature,
+		ResponseMIMEType: "application/json",
+		ResponseSchema: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"allowed":    {Type: genai.TypeBoolean},
+				"categories": {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString, Enum: enum}},
+				"reason":     {Type: genai.TypeString},
+			},
+			Required: []string{"allowed", "categories"},
+		},
+		SafetySettings: c.defaultGenContentConfig.SafetySettings,
+	}
+
+	parts := []*genai.Part{genai.NewPartFromText(buildModerationPrompt(params.question(), params.Audio != nil))}
+	if params.Audio != nil {
+		part, err := newAudioPart(params.Audio)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, part)
+	}
+	contents := []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+
+	if c.config.RequestTimeout > 0 {
+		if _, deadlineSet := ctx.Deadline(); !deadlineSet {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.config.RequestTimeout)
+			defer cancel()
+		}
+	}
+	if err := c.rateLimiter.wait(ctx); err != nil {
+		return err
+	}
+	start := time.Now()
+	genaiClient, record := c.generationClient()
+	resp, err := genaiClient.Models.GenerateContent(ctx, c.config.ModerationModelName, contents, config)
+	record(err)
+	if err != nil {
+		err = newAPIErrorFromSDK(err, "query moderation failed")
+		c.metrics.RecordRequest(ctx, newRequestMetrics(c.config.ModerationModelName, time.Since(start), nil, err))
+		return err
+	}
+	c.metrics.RecordRequest(ctx, newRequestMetrics(c.config.ModerationModelName, time.Since(start), &Response{RawResponse: resp}, nil))
+
+	// The moderation request itself may be blocked, which is a rejection in its own right.
+	if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" && resp.PromptFeedback.BlockReason != genai.BlockedReasonUnspecified {
+		return &QueryRejectedError{
+			Categories: blockedCategories(newSafetyRatings(resp.PromptFeedback.SafetyRatings)),
+			Reason:     fmt.Sprintf("prompt blocked due to %s", resp.PromptFeedback.BlockReason),
+		}
+	}
+
+	var verdict moderationVerdict
+	if err := json.Unmarshal([]byte(resp.Text()), &verdict); err != nil {
+		return ierrors.Wrap(err, "failed to parse query moderation result")
+	}
+	if verdict.Allowed {
+		return nil
+	}
+
+	rejected := &QueryRejectedError{Reason: verdict.Reason}
+	for _, cat := range verdict.Categories {
+		rejected.Categories = append(rejected.Categories, HarmCategory(cat))
+	}
+	return rejected
+}
+
+// blockedCategories returns the categories of the ratings that caused a block.
+func blockedCategories(ratings []SafetyRating) []HarmCategory {
+	var cats []HarmCategory
+	for _, r := range ratings {
+		if r.Blocked {
+			cats = append(cats, r.Category)
+		}
+	}
+	return cats
+}
+
+// buildModerationPrompt builds the classification prompt for the moderation model. If spoken
+// is true, the query is (also) spoken in an audio clip attached after the prompt.
+func buildModerationPrompt(prompt string, spoken bool) string {
+	if spoken {
+		prompt = strings.TrimSpace(prompt + "\n" + `(The query is also spoken in the attached audio clip. Classify what is said in it as part of the query.)`)
+	}
+	return `You are a content moderation classifier for a web search assistant.
+Decide whether the user query below may be answered. Reject only queries that seek harassment,
+hate speech, sexually explicit content, or dangerous content (e.g., instructions for weapons or
+serious harm). Ordinary questions about sensitive topics (news, history, health, security research)
+are allowed. Do not answer the query itself.
+
+<user_query>
+` + prompt + `
+</user_query>`
+}
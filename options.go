@@ -1,10 +1,18 @@
 package search
 
 import (
+	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
+	"cloud.google.com/go/auth"
+	"cloud.google.com/go/auth/credentials"
 	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"github.com/cnosuke/go-gemini-grounded-search/store"
+	"google.golang.org/genai"
 )
 
 // ClientOption is a function type used to apply configuration options to a ClientConfig.
@@ -15,16 +23,57 @@ type ClientOption func(*ClientConfig) error
 
 // WithModelName sets the default model name for the client.
 // This model will be used for requests unless overridden by GenerationParams.
+// Both plain model IDs (e.g., "gemini-3.5-flash") and fully qualified resource
+// names (e.g., "models/gemini-3.5-flash") are accepted.
 func WithModelName(name string) ClientOption {
 	return func(cfg *ClientConfig) error {
-		if name == "" {
-			return ErrInvalidModelName
+		if err := validateModelName(name); err != nil {
+			return err
 		}
 		cfg.ModelName = name
 		return nil
 	}
 }
 
+// WithTunedModel sets a fine-tuned model as the default model for the client.
+// name must be a tuned model resource name such as "tunedModels/my-model",
+// "projects/my-project/tunedModels/my-model", or a Vertex AI endpoint
+// ("projects/my-project/locations/us-central1/endpoints/1234").
+// The Google Search Tool remains enabled unless disabled explicitly.
+func WithTunedModel(name string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if err := validateModelName(name); err != nil {
+			return err
+		}
+		if !isTunedModelName(name) {
+			return ierrors.Wrapf(ErrInvalidModelName, "%q is not a tuned model resource name", name)
+		}
+		cfg.ModelName = name
+		return nil
+	}
+}
+
+// WithAPIKeys spreads generation requests over the given API keys in addition to the key passed
+// to NewClient, rotating round-robin per request. A key that hits a quota error is skipped for a
+// minute before it is used again. Keys that duplicate another key are ignored.
+// Uploaded files and cached content belong to the project of the NewClient key, which is also used
+// for every call other than generation; with keys from different projects, files and caches are
+// not accessible to requests sent with the other keys.
+func WithAPIKeys(keys []string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		for _, key := range keys {
+			if key == "" {
+				return ierrors.Wrap(ErrInvalidParameter, "API keys cannot be empty")
+			}
+			if key == cfg.APIKey || slices.Contains(cfg.APIKeys, key) {
+				continue
+			}
+			cfg.APIKeys = append(cfg.APIKeys, key)
+		}
+		return nil
+	}
+}
+
 // WithDefaultTemperature sets the default sampling temperature for the client.
 // Valid range is typically [0.0, 2.0].
 func WithDefaultTemperature(temp float32) ClientOption {
@@ -73,6 +122,50 @@ func WithDefaultTopP(p float32) ClientOption {
 	}
 }
 
+// WithDefaultSeed sets the default random seed used for decoding, so that regression tests and
+// evaluations get deterministic outputs where the model supports it. Grounded answers can still
+// vary when search results change.
+func WithDefaultSeed(seed int32) ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.DefaultSeed = &seed
+		return nil
+	}
+}
+
+// WithDefaultPresencePenalty sets the default presence penalty for the client.
+// Positive values discourage the model from repeating content it has already generated,
+// which helps when summarizing many similar sources. Valid values are in [-2.0, 2.0).
+func WithDefaultPresencePenalty(penalty float32) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if err := validatePenalty("presence penalty", penalty); err != nil {
+			return err
+		}
+		cfg.DefaultPresencePenalty = &penalty
+		return nil
+	}
+}
+
+// WithDefaultFrequencyPenalty sets the default frequency penalty for the client.
+// Positive values penalize tokens the more often they have already been generated.
+// Valid values are in [-2.0, 2.0).
+func WithDefaultFrequencyPenalty(penalty float32) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if err := validatePenalty("frequency penalty", penalty); err != nil {
+			return err
+		}
+		cfg.DefaultFrequencyPenalty = &penalty
+		return nil
+	}
+}
+
+// validatePenalty checks that a presence or frequency penalty is within the range the API accepts.
+func validatePenalty(kind string, penalty float32) error {
+	if penalty < -2.0 || penalty >= 2.0 {
+		return ierrors.Wrapf(ErrInvalidParameter, "%s must be in [-2.0, 2.0), got %f", kind, penalty)
+	}
+	return nil
+}
+
 // WithDefaultSafetySettings sets the default safety settings for the client.
 func WithDefaultSafetySettings(settings []*SafetySetting) ClientOption {
 	return func(cfg *ClientConfig) error {
@@ -108,14 +201,83 @@ func WithDefaultSafetySettings(settings []*SafetySetting) ClientOption {
 //	search.WithDefaultThinkingConfig(&search.ThinkingConfig{ThinkingBudget: &budget})
 func WithDefaultThinkingConfig(tc *ThinkingConfig) ClientOption {
 	return func(cfg *ClientConfig) error {
-		if tc != nil && tc.ThinkingBudget != nil && *tc.ThinkingBudget < 0 {
-			return ierrors.Wrapf(ErrInvalidParameter, "thinking budget must be non-negative, got %d", *tc.ThinkingBudget)
+		if tc != nil && tc.ThinkingBudget != nil && *tc.ThinkingBudget < -1 {
+			return ierrors.Wrapf(ErrInvalidParameter, "thinking budget must be non-negative or -1 (dynamic), got %d", *tc.ThinkingBudget)
 		}
 		cfg.DefaultThinkingConfig = tc
 		return nil
 	}
 }
 
+// WithResponseMIMEType sets the default MIME type of the generated text,
+// e.g., "application/json" to receive raw JSON without markdown fences.
+func WithResponseMIMEType(mimeType string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if mimeType == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "response MIME type cannot be empty")
+		}
+		cfg.ResponseMIMEType = mimeType
+		return nil
+	}
+}
+
+// WithTools adds arbitrary SDK tools to every request, composed with the Google Search Tool
+// (unless disabled) and any other tools enabled by the client.
+// Use WithFunctions instead for function declarations that should be executed by the client.
+func WithTools(tools []*genai.Tool) ClientOption {
+	return func(cfg *ClientConfig) error {
+		for _, t := range tools {
+			if t == nil {
+				return ierrors.Wrap(ErrInvalidParameter, "tool cannot be nil")
+			}
+		}
+		cfg.Tools = append(cfg.Tools, tools...)
+		return nil
+	}
+}
+
+// WithFunctions registers user-defined functions the model may call alongside the Google Search Tool.
+// When the model requests a function call, the matching handler is executed and its result is
+// sent back to the model until it produces a final answer. Function names must be unique across
+// all WithFunctions options and the per-request GenerationParams.Functions.
+func WithFunctions(fns ...Function) ClientOption {
+	return func(cfg *ClientConfig) error {
+		registered := make(map[string]Function, len(cfg.Functions))
+		for _, fn := range cfg.Functions {
+			registered[fn.Declaration.Name] = fn
+		}
+		if err := validateFunctions(fns, registered); err != nil {
+			return err
+		}
+		cfg.Functions = append(cfg.Functions, fns...)
+		return nil
+	}
+}
+
+// WithSearchScopes registers named search scopes that requests can select via GenerationParams.Scope,
+// so per-vertical policy (allowed domains, language, recency, citation style) is defined in one place.
+func WithSearchScopes(scopes ...SearchScope) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if err := validateSearchScopes(scopes, cfg.SearchScopes); err != nil {
+			return err
+		}
+		cfg.SearchScopes = append(cfg.SearchScopes, scopes...)
+		return nil
+	}
+}
+
+// WithMaxFunctionCallRounds sets the maximum number of function-call round-trips per request.
+// Must be positive.
+func WithMaxFunctionCallRounds(n int) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if n <= 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "max function call rounds must be positive, got %d", n)
+		}
+		cfg.MaxFunctionCallRounds = n
+		return nil
+	}
+}
+
 // WithHTTPClient sets a custom HTTP client to be used for API requests.
 func WithHTTPClient(client *http.Client) ClientOption {
 	return func(cfg *ClientConfig) error {
@@ -127,6 +289,271 @@ func WithHTTPClient(client *http.Client) ClientOption {
 	}
 }
 
+// WithProxy routes API requests and URL-resolution requests through the HTTP proxy at proxyURL
+// (e.g., "http://proxy.corp.example.com:8080"). It can be combined with WithHTTPClient as long
+// as the custom client uses an *http.Transport (or the default transport).
+func WithProxy(proxyURL string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return ierrors.Wrapf(ErrInvalidParameter, "invalid proxy URL %q", proxyURL)
+		}
+		cfg.ProxyURL = u
+		return nil
+	}
+}
+
+// WithTransportTuning tunes connection pooling of the HTTP transport shared by API requests and
+// URL-resolution requests, so that busy servers reuse connections instead of opening new ones.
+// maxIdleConns limits the idle connections kept open (also per host), idleTimeout is how long
+// they are kept, and maxConnsPerHost limits all connections to a single host. A zero value
+// leaves the corresponding setting of the transport unchanged. It can be combined with
+// WithHTTPClient as long as the custom client uses an *http.Transport (or the default transport).
+func WithTransportTuning(maxIdleConns int, idleTimeout time.Duration, maxConnsPerHost int) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if maxIdleConns < 0 || idleTimeout < 0 || maxConnsPerHost < 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "transport tuning values cannot be negative, got maxIdleConns=%d, idleTimeout=%s, maxConnsPerHost=%d", maxIdleConns, idleTimeout, maxConnsPerHost)
+		}
+		cfg.MaxIdleConns = maxIdleConns
+		cfg.IdleConnTimeout = idleTimeout
+		cfg.MaxConnsPerHost = maxConnsPerHost
+		return nil
+	}
+}
+
+// WithRequestCompression gzip-compresses the JSON bodies of API requests of at least 1 KiB,
+// which shortens uploads of long prompts over slow or distant links. Only enable it for backends
+// that accept gzip-encoded requests, as the Gemini API does. Responses are compressed regardless
+// of this option, as Go's HTTP transport requests and decodes gzip-compressed responses itself.
+func WithRequestCompression() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.RequestCompression = true
+		return nil
+	}
+}
+
+// WithResolverOptions customizes the User-Agent, Accept-Language, extra headers, and TLS
+// configuration of the requests that resolve grounding redirect URLs (see WithNoRedirection).
+// It does not affect API requests. Headers are merged into any headers set by earlier options.
+func WithResolverOptions(opts ResolverOptions) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if opts.UserAgent != "" {
+			cfg.ResolverOptions.UserAgent = opts.UserAgent
+		}
+		if opts.AcceptLanguage != "" {
+			cfg.ResolverOptions.AcceptLanguage = opts.AcceptLanguage
+		}
+		if len(opts.Headers) > 0 {
+			if cfg.ResolverOptions.Headers == nil {
+				cfg.ResolverOptions.Headers = make(http.Header)
+			}
+			for k, v := range opts.Headers {
+				cfg.ResolverOptions.Headers[k] = append([]string(nil), v...)
+			}
+		}
+		if opts.TLSConfig != nil {
+			cfg.ResolverOptions.TLSConfig = opts.TLSConfig
+		}
+		return nil
+	}
+}
+
+// WithHTTPOptions customizes the base URL, API version, extra headers, and TLS configuration used
+// for API requests. This is typically needed when Gemini traffic is routed through an internal
+// gateway (see also WithBaseURL). Headers are merged into any headers set by earlier options.
+func WithHTTPOptions(opts HTTPOptions) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if opts.BaseURL != "" {
+			u, err := url.Parse(opts.BaseURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return ierrors.Wrapf(ErrInvalidParameter, "base URL %q must be an absolute http or https URL", opts.BaseURL)
+			}
+			cfg.HTTPOptions.BaseURL = opts.BaseURL
+		}
+		if opts.APIVersion != "" {
+			cfg.HTTPOptions.APIVersion = opts.APIVersion
+		}
+		if len(opts.Headers) > 0 {
+			if cfg.HTTPOptions.Headers == nil {
+				cfg.HTTPOptions.Headers = make(http.Header)
+			}
+			for key, values := range opts.Headers {
+				for _, v := range values {
+					cfg.HTTPOptions.Headers.Add(key, v)
+				}
+			}
+		}
+		if opts.TLSConfig != nil {
+			cfg.HTTPOptions.TLSConfig = opts.TLSConfig
+		}
+		return nil
+	}
+}
+
+// WithBaseURL sends API requests to baseURL instead of the Google endpoint, e.g., an LLM gateway
+// such as LiteLLM or an internal proxy that all LLM traffic must go through. The gateway must
+// forward requests in the Gemini API format (for LiteLLM, its Gemini pass-through endpoint),
+// since grounding metadata is not carried by OpenAI-compatible endpoints; responses are parsed
+// as usual. Headers the gateway requires, such as its own credentials, and a custom TLS
+// configuration can be set with WithHTTPOptions:
+//
+//	search.NewClient(ctx, apiKey,
+//		search.WithBaseURL("https://llm-gateway.internal.example.com/gemini"),
+//		search.WithHTTPOptions(search.HTTPOptions{
+//			Headers:   http.Header{"Authorization": {"Bearer " + gatewayToken}},
+//			TLSConfig: &tls.Config{RootCAs: corporateCAs},
+//		}),
+//	)
+func WithBaseURL(baseURL string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if strings.TrimSpace(baseURL) == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "base URL cannot be empty")
+		}
+		return WithHTTPOptions(HTTPOptions{BaseURL: baseURL})(cfg)
+	}
+}
+
+// WithUserAgentSuffix appends s (e.g., "my-app/2.1") to the User-Agent sent with API and
+// URL-resolution requests, so the application can be identified in upstream logs.
+func WithUserAgentSuffix(s string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "user agent suffix cannot be empty")
+		}
+		if strings.ContainsAny(s, "\r\n") {
+			return ierrors.Wrap(ErrInvalidParameter, "user agent suffix cannot contain line breaks")
+		}
+		cfg.UserAgentSuffix = s
+		return nil
+	}
+}
+
+// WithLogger sets the structured logger used for the client's diagnostics, such as
+// request start/finish (debug), retries (info), and URL-resolution failures (warn).
+// By default the client does not log anything.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if logger == nil {
+			return ierrors.Wrap(ErrInvalidParameter, "logger cannot be nil if provided")
+		}
+		cfg.Logger = logger
+		return nil
+	}
+}
+
+// WithRateLimit limits the API calls made by the client (across all goroutines) to rps per second,
+// allowing bursts of up to burst calls. Calls wait for capacity instead of failing, which keeps
+// applications sharing one API key under quota. rps must be positive and burst at least 1.
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if rps <= 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "rate limit must be positive, got %f", rps)
+		}
+		if burst < 1 {
+			return ierrors.Wrapf(ErrInvalidParameter, "rate limit burst must be at least 1, got %d", burst)
+		}
+		cfg.RateLimit = rps
+		cfg.RateLimitBurst = burst
+		return nil
+	}
+}
+
+// WithCircuitBreaker enables a circuit breaker that opens after threshold consecutive server
+// errors returned by the API (HTTP 5xx, Internal, Unavailable). Canceled or timed-out contexts
+// and failures to reach the API are not counted. While open, requests fail fast with
+// ErrCircuitOpen. After cooldown a single trial request is let through; unless the API answers
+// it with a server error, it closes the circuit again.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if threshold <= 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "circuit breaker threshold must be positive, got %d", threshold)
+		}
+		if cooldown <= 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "circuit breaker cooldown must be positive, got %v", cooldown)
+		}
+		cfg.CircuitBreakerThreshold = threshold
+		cfg.CircuitBreakerCooldown = cooldown
+		return nil
+	}
+}
+
+// WithInterceptor adds middleware around every generation call, e.g., for logging, caching,
+// authentication, or prompt rewriting. Interceptors run in the order they are added;
+// the first one added is the outermost.
+func WithInterceptor(interceptor Interceptor) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if interceptor == nil {
+			return ierrors.Wrap(ErrInvalidParameter, "interceptor cannot be nil")
+		}
+		cfg.Interceptors = append(cfg.Interceptors, interceptor)
+		return nil
+	}
+}
+
+// WithPromptRedactor sets a function applied to prompts before they appear in debug logs or are
+// passed to hooks (see WithOnRequest), e.g., to mask e-mail addresses or customer IDs. The
+// prompt sent to the API is not changed. Debug logs include prompt text only when a redactor
+// is set; otherwise they log just its length.
+func WithPromptRedactor(redactor func(string) string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if redactor == nil {
+			return ierrors.Wrap(ErrInvalidParameter, "prompt redactor cannot be nil")
+		}
+		cfg.PromptRedactor = redactor
+		return nil
+	}
+}
+
+// WithOnRequest adds a hook called with the parameters of every generation call as it starts.
+// Hooks are called synchronously on the request path, so they should not block.
+func WithOnRequest(hook RequestHook) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if hook == nil {
+			return ierrors.Wrap(ErrInvalidParameter, "request hook cannot be nil")
+		}
+		cfg.OnRequest = append(cfg.OnRequest, hook)
+		return nil
+	}
+}
+
+// WithOnResponse adds a hook called with the response and duration of every successful
+// generation call. Hooks are called synchronously on the request path, so they should not block.
+func WithOnResponse(hook ResponseHook) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if hook == nil {
+			return ierrors.Wrap(ErrInvalidParameter, "response hook cannot be nil")
+		}
+		cfg.OnResponse = append(cfg.OnResponse, hook)
+		return nil
+	}
+}
+
+// WithOnError adds a hook called with the error, its class, and the duration of every failed
+// generation call, e.g., to report incidents. Hooks are called synchronously on the request
+// path, so they should not block.
+func WithOnError(hook ErrorHook) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if hook == nil {
+			return ierrors.Wrap(ErrInvalidParameter, "error hook cannot be nil")
+		}
+		cfg.OnError = append(cfg.OnError, hook)
+		return nil
+	}
+}
+
+// WithMetricsRecorder sets the recorder that receives request counts, latency, token usage,
+// error classes, and URL-resolution outcomes.
+func WithMetricsRecorder(recorder MetricsRecorder) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if recorder == nil {
+			return ierrors.Wrap(ErrInvalidParameter, "metrics recorder cannot be nil if provided")
+		}
+		cfg.MetricsRecorder = recorder
+		return nil
+	}
+}
+
 // WithRequestTimeout sets the default timeout for API requests made by the client.
 // Must not be negative. A value of 0 means no timeout at this level.
 func WithRequestTimeout(timeout time.Duration) ClientOption {
@@ -139,6 +566,136 @@ func WithRequestTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
+// WithSoftTimeout makes the client stream responses and stop generation after d, returning
+// the text and grounding metadata received so far with Response.Partial set, rather than failing
+// with a deadline error. If nothing was received by then, the error is returned as usual.
+// d should be shorter than the request timeout to have any effect.
+func WithSoftTimeout(d time.Duration) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if d <= 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "soft timeout must be positive, got %v", d)
+		}
+		cfg.SoftTimeout = d
+		return nil
+	}
+}
+
+// WithVertexAI makes the client use the Vertex AI backend (express mode, authenticated
+// with the API key passed to NewClient) instead of the Gemini API.
+func WithVertexAI() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.Backend = BackendVertexAI
+		return nil
+	}
+}
+
+// WithVertexAIProject makes the client use the Vertex AI backend of the given Google Cloud project
+// and location (e.g., "us-central1"; "global" if empty), authenticated with OAuth instead of an
+// API key, for environments that prohibit long-lived API keys. Requests use Application Default
+// Credentials (e.g., workload identity or a service account attached to the runtime) unless
+// WithCredentials is also given. Pass an empty API key to NewClient.
+func WithVertexAIProject(project, location string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if strings.TrimSpace(project) == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "Google Cloud project cannot be empty")
+		}
+		cfg.Backend = BackendVertexAI
+		cfg.Project = project
+		cfg.Location = location
+		return nil
+	}
+}
+
+// WithCredentials authenticates requests with creds instead of Application Default Credentials,
+// e.g., credentials of a specific service account or from a custom token provider.
+// Requires WithVertexAIProject.
+func WithCredentials(creds *auth.Credentials) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if creds == nil {
+			return ierrors.Wrap(ErrInvalidParameter, "credentials cannot be nil")
+		}
+		cfg.Credentials = creds
+		return nil
+	}
+}
+
+// WithCredentialsFile authenticates requests with the service account key or other credentials
+// file at path, without relying on the GOOGLE_APPLICATION_CREDENTIALS environment variable.
+// Requires WithVertexAIProject.
+func WithCredentialsFile(path string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if path == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "credentials file path cannot be empty")
+		}
+		creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+			Scopes:          []string{cloudPlatformScope},
+			CredentialsFile: path,
+		})
+		if err != nil {
+			return ierrors.Wrapf(ErrInvalidParameter, "failed to load credentials file %s: %v", path, err)
+		}
+		cfg.Credentials = creds
+		return nil
+	}
+}
+
+// WithCredentialsJSON authenticates requests with the given service account key or other
+// credentials JSON, e.g., read from a secret manager. Requires WithVertexAIProject.
+func WithCredentialsJSON(data []byte) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if len(data) == 0 {
+			return ierrors.Wrap(ErrInvalidParameter, "credentials JSON cannot be empty")
+		}
+		creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+			Scopes:          []string{cloudPlatformScope},
+			CredentialsJSON: data,
+		})
+		if err != nil {
+			return ierrors.Wrapf(ErrInvalidParameter, "failed to parse credentials JSON: %v", err)
+		}
+		cfg.Credentials = creds
+		return nil
+	}
+}
+
+// WithRecording records all Gemini API and URL-resolution traffic to dir, one JSON file per
+// request, for later use with WithReplay in deterministic, offline integration tests.
+// API keys are removed from the recordings; request headers are not recorded.
+func WithRecording(dir string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if dir == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "recording directory cannot be empty")
+		}
+		cfg.RecordingDir = dir
+		return nil
+	}
+}
+
+// WithReplay answers all Gemini API and URL-resolution requests from the recordings in dir,
+// made with WithRecording, without contacting the network. Requests are matched by method, URL,
+// and body; identical requests are answered in recorded order. Requests without a recording
+// fail with ErrNoRecording.
+func WithReplay(dir string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if dir == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "replay directory cannot be empty")
+		}
+		cfg.ReplayDir = dir
+		return nil
+	}
+}
+
+// WithEnterpriseWebSearch replaces the Google Search Tool with Vertex AI's enterprise
+// web search tool, which grounds answers in compliance-filtered web results.
+// Grounding output is extracted into GroundingAttributions exactly as for Google Search.
+// Requires WithVertexAI.
+func WithEnterpriseWebSearch() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.EnterpriseWebSearch = true
+		return nil
+	}
+}
+
 // WithGoogleSearchToolDisabled allows disabling the Google Search Tool globally for the client.
 func WithGoogleSearchToolDisabled(disabled bool) ClientOption {
 	return func(cfg *ClientConfig) error {
@@ -147,6 +704,16 @@ func WithGoogleSearchToolDisabled(disabled bool) ClientOption {
 	}
 }
 
+// WithURLContext enables the URL Context tool so answers can be grounded in specific pages
+// given in the prompt or via GenerationParams.ContextURLs. It is used together with the
+// Google Search Tool unless that is disabled with WithGoogleSearchToolDisabled.
+func WithURLContext() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.EnableURLContext = true
+		return nil
+	}
+}
+
 // WithNoRedirection disables URL redirection and keeps the original URL.
 func WithNoRedirection() ClientOption {
 	return func(cfg *ClientConfig) error {
@@ -155,6 +722,253 @@ func WithNoRedirection() ClientOption {
 	}
 }
 
+// WithOfflineURLDecoding makes URL resolution decode grounding redirect URLs that carry their
+// target (in a query parameter, or encoded in the path) locally instead of issuing a HEAD request
+// for each of them. URLs that cannot be decoded, such as opaque grounding redirect tokens,
+// are still resolved over the network. It has no effect unless URL resolution is enabled
+// (see WithNoRedirection and GenerationParams.ResolveURLs).
+func WithOfflineURLDecoding() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.OfflineURLDecoding = true
+		return nil
+	}
+}
+
+// WithInjectionGuard adds a defense against prompt injection through grounded content: prompts
+// are wrapped in instructions telling the model to treat search results as untrusted data, and
+// responses are scanned for instruction-like phrases (e.g., "ignore previous instructions") in
+// source titles, in fetched source text if WithSourceEnrichment is enabled, and in the generated
+// text. Findings are reported in Response.SecurityFlags; responses are not blocked. Questions
+// about prompt injection itself may be flagged, too.
+func WithInjectionGuard() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.InjectionGuard = true
+		return nil
+	}
+}
+
+// WithSourceEnrichment fetches the page of every source cited by a response, extracts its main
+// text (leaving out navigation, headers, footers, and similar boilerplate), and attaches a
+// snippet, word count, publication date, and site metadata (canonical URL, favicon, site name,
+// and OpenGraph title and description) to the attribution, for verification or source cards.
+// Pages are fetched after URL resolution and attribution filtering, with the URL resolution
+// concurrency and per-host limits, and share its deadline. Sources that cannot be fetched are
+// left unchanged.
+func WithSourceEnrichment() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.EnrichSources = true
+		return nil
+	}
+}
+
+// WithSourceValidation checks every source cited by a response for dead links: each source URL
+// is requested (following redirects) and the final status is recorded in
+// GroundingAttribution.HTTPStatus and Reachable, so applications can hide or demote broken
+// citations. Validation runs after URL resolution and attribution filtering, with the URL
+// resolution concurrency and per-host limits. WithSourceEnrichment records the status as well.
+func WithSourceValidation() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.ValidateSources = true
+		return nil
+	}
+}
+
+// WithArchiveFallback attaches an Internet Archive (Wayback Machine) snapshot to sources that no
+// longer exist: when a source page responds with 404 Not Found or 410 Gone, the snapshot closest to
+// the time the source was accessed is looked up and set as GroundingAttribution.ArchiveURL.
+// Sources are only checked by source validation and enrichment, so it has no effect without
+// WithSourceValidation or WithSourceEnrichment.
+func WithArchiveFallback() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.ArchiveFallback = true
+		return nil
+	}
+}
+
+// WithQueryModeration enables a cheap moderation pass on every query before the grounded request.
+// Disallowed queries fail fast with a *QueryRejectedError (matching ErrQueryRejected) that lists
+// the harm categories, without spending tokens on the grounded model.
+// Spoken queries (see GenerationParams.Audio) are moderated by sending the audio clip along.
+// If the moderation call itself fails, its error is returned and the query is not sent.
+func WithQueryModeration() ClientOption {
+	return func(cfg *ClientConfig) error {
+		cfg.QueryModeration = true
+		return nil
+	}
+}
+
+// WithModerationModelName sets the model used by WithQueryModeration.
+func WithModerationModelName(name string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if err := validateModelName(name); err != nil {
+			return err
+		}
+		cfg.ModerationModelName = name
+		return nil
+	}
+}
+
+// WithModelRouter routes every request that does not set GenerationParams.ModelName to a fast or
+// a strong model depending on the complexity of its query, so that simple lookups do not pay for
+// a pro model. Unless policy.Classifier or policy.PreflightModel is set, queries are classified
+// with local heuristics (length, attachments, and multi-hop phrasing such as comparisons).
+// Empty model names in policy default to DefaultModelName and DefaultComplexModelName.
+// The routed model is recorded in Response.Attempts.
+func WithModelRouter(policy ModelRoutingPolicy) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if policy.SimpleModel == "" {
+			policy.SimpleModel = DefaultModelName
+		}
+		if policy.ComplexModel == "" {
+			policy.ComplexModel = DefaultComplexModelName
+		}
+		for _, name := range []string{policy.SimpleModel, policy.ComplexModel} {
+			if err := validateModelName(name); err != nil {
+				return err
+			}
+		}
+		if policy.PreflightModel != "" {
+			if err := validateModelName(policy.PreflightModel); err != nil {
+				return err
+			}
+		}
+		cfg.ModelRouter = &policy
+		return nil
+	}
+}
+
+// WithCachedContent makes every request use the cached content with the given name, created
+// with Client.CreateCachedContent, instead of sending its system instruction and documents again.
+// Requests must use the model the cache was created for.
+func WithCachedContent(name string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if strings.TrimSpace(name) == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "cached content name cannot be empty")
+		}
+		cfg.CachedContent = name
+		return nil
+	}
+}
+
+// WithUserLocation tells the model where the user is, so that searches for location-dependent
+// questions ("current electricity prices") prefer sources, currencies, and units of the user's
+// country rather than, say, US results. countryCode is an ISO 3166-1 alpha-2 code (e.g., "JP");
+// city is optional. The location is sent in a delimited section of the prompt.
+func WithUserLocation(countryCode, city string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		loc := &UserLocation{CountryCode: countryCode, City: city}
+		if err := loc.validate(); err != nil {
+			return err
+		}
+		cfg.UserLocation = loc
+		return nil
+	}
+}
+
+// WithMinGroundingConfidence drops grounding attributions the model is not confident about:
+// attributions whose segments are all scored below score are removed from responses.
+// Segments without a confidence score (newer models do not report them) are never considered
+// below the threshold. score must be in [0, 1]; 0 disables the filter.
+func WithMinGroundingConfidence(score float32) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if score < 0 || score > 1 {
+			return ierrors.Wrapf(ErrInvalidParameter, "min grounding confidence must be between 0.0 and 1.0, got %f", score)
+		}
+		cfg.MinGroundingConfidence = score
+		return nil
+	}
+}
+
+// WithAllowedDomains keeps only the attributions from the given domains and their subdomains
+// (e.g., "gov" and "edu" to keep only .gov and .edu sources). URL resolution is enabled for
+// requests with domain filters (see WithNoRedirection), since the Gemini API reports no domain
+// for grounding redirect URLs, and the filter matches the real source domain. Attributions whose
+// domain cannot be determined are removed.
+func WithAllowedDomains(domains ...string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if err := validateDomains("allowed", domains); err != nil {
+			return err
+		}
+		cfg.AllowedDomains = append(cfg.AllowedDomains, domains...)
+		return nil
+	}
+}
+
+// WithBlockedDomains removes the attributions from the given domains and their subdomains
+// (e.g., "pinterest.com"). Like WithAllowedDomains, it enables URL resolution and is applied
+// to the resolved source domains.
+func WithBlockedDomains(domains ...string) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if err := validateDomains("blocked", domains); err != nil {
+			return err
+		}
+		cfg.BlockedDomains = append(cfg.BlockedDomains, domains...)
+		return nil
+	}
+}
+
+// WithMinDistinctDomains requires responses to cite at least n distinct source domains.
+// If the first response cites fewer, the request is retried once with an instruction to
+// consult additional independent sources. Whether the requirement was ultimately met is
+// reported in Response.DomainDiversityMet. Must be positive.
+func WithMinDistinctDomains(n int) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if n <= 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "min distinct domains must be positive, got %d", n)
+		}
+		cfg.MinDistinctDomains = n
+		return nil
+	}
+}
+
+// WithMaxConcurrentRequestsPerHost sets how many requests the client may send to the same host
+// at once while resolving or fetching source URLs. Must not be negative; 0 disables the limit.
+func WithMaxConcurrentRequestsPerHost(n int) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if n < 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "max concurrent requests per host cannot be negative, got %d", n)
+		}
+		cfg.MaxConcurrentRequestsPerHost = n
+		return nil
+	}
+}
+
+// WithURLResolutionConcurrency sets how many grounding URLs are resolved concurrently for a
+// response (default: DefaultURLResolutionConcurrency). Raise it on high-throughput servers, or
+// lower it to 1 or 2 in rate-sensitive environments; per-host limits set with
+// WithMaxConcurrentRequestsPerHost still apply. Must be positive.
+func WithURLResolutionConcurrency(n int) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if n <= 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "URL resolution concurrency must be positive, got %d", n)
+		}
+		cfg.URLResolutionConcurrency = n
+		return nil
+	}
+}
+
+// WithURLResolutionCache caches resolved grounding redirect URLs, so that sources cited again
+// in later responses are not resolved with another HEAD request. Entries are kept in s, which
+// may be shared with other clients or persisted (see the store package); if s is nil, an
+// in-process store.Memory is used. Entries expire after ttl, or DefaultURLResolutionCacheTTL
+// if ttl is zero. ttl must not be negative.
+func WithURLResolutionCache(s store.Store, ttl time.Duration) ClientOption {
+	return func(cfg *ClientConfig) error {
+		if ttl < 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "URL resolution cache TTL cannot be negative, got %v", ttl)
+		}
+		if ttl == 0 {
+			ttl = DefaultURLResolutionCacheTTL
+		}
+		if s == nil {
+			s = store.NewMemory()
+		}
+		cfg.URLResolutionCache = s
+		cfg.URLResolutionCacheTTL = ttl
+		return nil
+	}
+}
+
 // applyClientOptions applies the given options to the ClientConfig.
 // This is an unexported helper function called by NewClient.
 func applyClientOptions(cfg *ClientConfig, opts ...ClientOption) error {
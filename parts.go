@@ -0,0 +1,97 @@
+package search
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"google.golang.org/genai"
+)
+
+// buildUserContent assembles the user turn sent to the model: the prompt text followed by
+// any media attached to the request.
+func buildUserContent(params *GenerationParams, location *UserLocation) (*genai.Content, error) {
+	parts := []*genai.Part{genai.NewPartFromText(buildPromptText(params, location))}
+
+	for i, img := range params.Images {
+		mimeType := http.DetectContentType(img)
+		if !strings.HasPrefix(mimeType, "image/") {
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "image %d is not a recognized image format (detected %s)", i, mimeType)
+		}
+		parts = append(parts, genai.NewPartFromBytes(img, mimeType))
+	}
+	for _, uri := range params.ImageURIs {
+		mimeType, err := imageMIMEType(uri)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, genai.NewPartFromURI(uri, mimeType))
+	}
+
+	if params.Audio != nil {
+		part, err := newAudioPart(params.Audio)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	for _, f := range params.Files {
+		if f.URI == "" || f.MIMEType == "" {
+			return nil, ierrors.Wrapf(ErrInvalidParameter, "file %q must have a URI and a MIME type", f.DisplayName)
+		}
+		parts = append(parts, genai.NewPartFromURI(f.URI, f.MIMEType))
+	}
+
+	return genai.NewContentFromParts(parts, genai.RoleUser), nil
+}
+
+// newAudioPart converts an audio clip into a part, detecting its MIME type if it is not given.
+func newAudioPart(a *AudioInput) (*genai.Part, error) {
+	mimeType := a.MIMEType
+	if mimeType == "" {
+		mimeType = detectAudioMIMEType(a.Data)
+	}
+	if len(a.Data) == 0 || mimeType == "" {
+		return nil, ierrors.Wrap(ErrInvalidParameter, "audio must have data in a recognized format, or a MIME type")
+	}
+	return genai.NewPartFromBytes(a.Data, mimeType), nil
+}
+
+// imageMIMEType validates an image URI and returns the MIME type implied by its extension.
+func imageMIMEType(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" {
+		return "", ierrors.Wrapf(ErrInvalidParameter, "image URI %q must be an absolute URI", uri)
+	}
+	mimeType, _, _ := mime.ParseMediaType(mime.TypeByExtension(strings.ToLower(path.Ext(u.Path))))
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", ierrors.Wrapf(ErrInvalidParameter, "cannot determine the image type of %q from its extension", uri)
+	}
+	return mimeType, nil
+}
+
+// detectAudioMIMEType returns the MIME type of audio data in one of the formats the model
+// accepts (WAV, MP3, AIFF, AAC, OGG, FLAC), or an empty string if it is not recognized.
+func detectAudioMIMEType(data []byte) string {
+	switch {
+	case len(data) >= 4 && string(data[:4]) == "fLaC":
+		return "audio/flac"
+	case len(data) >= 2 && data[0] == 0xFF && (data[1]&0xF6) == 0xF0:
+		return "audio/aac" // ADTS header
+	}
+	switch http.DetectContentType(data) {
+	case "audio/wave":
+		return "audio/wav"
+	case "audio/mpeg":
+		return "audio/mp3"
+	case "audio/aiff":
+		return "audio/aiff"
+	case "application/ogg":
+		return "audio/ogg"
+	}
+	return ""
+}
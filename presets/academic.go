@@ -0,0 +1,224 @@
+/*
+Package presets provides ready-made grounded-search configurations for common kinds of research.
+
+A preset compiles its source constraints into GenerationParams and reviews the sources of the
+responses it produced. Presets only use public features of the search package, so they can be
+copied and adapted when a variant is needed.
+
+Basic Usage:
+
+	academic := presets.AcademicSearch()
+	resp, err := client.GenerateGroundedContentWithParams(ctx,
+		academic.Params("What is the evidence for spaced repetition in language learning?"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	review := academic.Review(resp)
+	for _, src := range review.Sources {
+		fmt.Println(src.Attribution.Title, src.DOI)
+	}
+	for _, w := range review.Warnings {
+		log.Println("warning:", w)
+	}
+*/
+package presets
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	search "github.com/cnosuke/go-gemini-grounded-search"
+)
+
+// scholarlySites are the domains of journals, publishers, preprint servers, indexes, and
+// academic institutions that the academic preset searches. Top-level and second-level domains
+// such as "edu" and "ac.uk" cover the institutions registered under them.
+var scholarlySites = []string{
+	// Academic institutions.
+	"edu", "ac.uk", "ac.jp", "edu.au", "ac.nz", "ac.kr", "edu.cn",
+	// DOI resolution, indexes, and repositories.
+	"doi.org", "ncbi.nlm.nih.gov", "europepmc.org", "semanticscholar.org", "openalex.org",
+	"scholar.archive.org", "hal.science", "zenodo.org",
+	// Preprint servers.
+	"arxiv.org", "biorxiv.org", "medrxiv.org", "ssrn.com", "osf.io", "openreview.net",
+	// Journals and publishers.
+	"nature.com", "science.org", "cell.com", "pnas.org", "plos.org", "springer.com",
+	"sciencedirect.com", "wiley.com", "tandfonline.com", "sagepub.com", "academic.oup.com",
+	"cambridge.org", "jstor.org", "ieee.org", "acm.org", "aclanthology.org", "frontiersin.org",
+	"mdpi.com", "bmj.com", "thelancet.com", "nejm.org", "jamanetwork.com", "annualreviews.org",
+	"aps.org", "iop.org", "acs.org", "royalsocietypublishing.org",
+}
+
+// academicGuidance is the search guidance of the academic preset.
+const academicGuidance = "Base the answer on peer-reviewed journal articles, preprints, and publications of " +
+	"academic institutions. Prefer primary research and systematic reviews over news coverage of them, " +
+	"clearly mark findings that are only available as preprints, and include the DOI of cited papers when known."
+
+// doiPattern matches a DOI, e.g., "10.1038/s41586-020-2649-2".
+var doiPattern = regexp.MustCompile(`10\.\d{4,9}/[^\s?#]+`)
+
+// arxivIDPattern matches the identifier of an arXiv paper in an abs or pdf URL path.
+var arxivIDPattern = regexp.MustCompile(`^/(?:abs|pdf)/(\d{4}\.\d{4,5})(?:v\d+)?(?:\.pdf)?$`)
+
+// doiURLSuffixes are the path suffixes publishers append to DOIs in article URLs.
+var doiURLSuffixes = []string{"/abstract", "/full", "/pdf", "/epdf", "/fulltext", ".pdf"}
+
+// Academic is a preset for scholarly research: it restricts grounding to journals, preprint
+// servers, and academic institutions, and reviews responses for DOIs and off-list sources.
+// Its fields can be modified before use.
+type Academic struct {
+	// Sites are the scholarly domains searched (see search.GenerationParams.RestrictToSites).
+	Sites []string
+
+	// Guidance is the search guidance added to requests.
+	Guidance string
+}
+
+// AcademicSearch returns the academic research preset.
+func AcademicSearch() *Academic {
+	return &Academic{
+		Sites:    append([]string(nil), scholarlySites...),
+		Guidance: academicGuidance,
+	}
+}
+
+// Params returns GenerationParams for question with the preset's constraints.
+func (a *Academic) Params(question string) *search.GenerationParams {
+	return a.Apply(&search.GenerationParams{Prompt: question})
+}
+
+// Apply returns a copy of params with the preset's sites added to RestrictToSites and its
+// guidance added to SearchGuidance. URL resolution is enabled, since Review needs the origin
+// URLs of the sources to find their DOIs and domains.
+func (a *Academic) Apply(params *search.GenerationParams) *search.GenerationParams {
+	p := *params
+	resolve := true
+	p.ResolveURLs = &resolve
+	p.RestrictToSites = append(append([]string(nil), params.RestrictToSites...), a.Sites...)
+	if strings.TrimSpace(p.SearchGuidance) != "" {
+		p.SearchGuidance += "\n" + a.Guidance
+	} else {
+		p.SearchGuidance = a.Guidance
+	}
+	return &p
+}
+
+// Interceptor returns an interceptor that applies the preset to every request of a client
+// (see search.WithInterceptor).
+func (a *Academic) Interceptor() search.Interceptor {
+	return func(ctx context.Context, params *search.GenerationParams, next search.GenerateFunc) (*search.Response, error) {
+		if params == nil {
+			return next(ctx, params)
+		}
+		return next(ctx, a.Apply(params))
+	}
+}
+
+// ScholarlySource is a cited source reviewed by the academic preset.
+type ScholarlySource struct {
+	// Attribution is the cited source.
+	Attribution search.GroundingAttribution
+
+	// DOI is the Digital Object Identifier found in the source URL (e.g.,
+	// "10.1038/s41586-020-2649-2"), or empty if the URL contains none. arXiv URLs are mapped to
+	// the DOIs arXiv registers for its papers.
+	DOI string
+
+	// Scholarly reports whether the source is on one of the preset's sites.
+	Scholarly bool
+}
+
+// AcademicReview is the result of reviewing a response with the academic preset.
+type AcademicReview struct {
+	// Sources lists the cited sources in the order of the response.
+	Sources []ScholarlySource
+
+	// Warnings describe problems with the sources, e.g., sources outside scholarly domains.
+	// It is empty if there are none.
+	Warnings []string
+}
+
+// Review extracts the DOIs of the sources cited by resp and warns about sources outside the
+// preset's sites, and about responses without any source. It relies on the resolved source URLs
+// of responses to requests made with Params or Apply; the sources of other responses may be
+// reported as not scholarly.
+func (a *Academic) Review(resp *search.Response) *AcademicReview {
+	review := &AcademicReview{}
+	for _, attr := range resp.GroundingAttributions {
+		src := ScholarlySource{
+			Attribution: attr,
+			DOI:         ExtractDOI(attr.URL),
+			Scholarly:   a.isScholarly(attr),
+		}
+		if !src.Scholarly {
+			review.Warnings = append(review.Warnings, fmt.Sprintf("source %q (%s) is not from a scholarly domain", attr.Title, sourceLocation(attr)))
+		}
+		review.Sources = append(review.Sources, src)
+	}
+	if len(review.Sources) == 0 {
+		review.Warnings = append(review.Warnings, "the response cites no sources")
+	}
+	return review
+}
+
+// isScholarly reports whether attr is on one of the preset's sites.
+func (a *Academic) isScholarly(attr search.GroundingAttribution) bool {
+	host := attr.SourceDomain()
+	if host == "" {
+		return false
+	}
+	for _, site := range a.Sites {
+		site = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(site)), "."), ".")
+		if host == site || strings.HasSuffix(host, "."+site) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLocation returns the URL of attr, or its domain if it has no URL.
+func sourceLocation(attr search.GroundingAttribution) string {
+	if attr.URL != "" {
+		return attr.URL
+	}
+	return attr.Domain
+}
+
+// ExtractDOI returns the DOI contained in rawURL, such as a doi.org link or a publisher's
+// article URL, or an empty string if there is none. arXiv abstract and PDF URLs are mapped to
+// their arXiv DOIs (e.g., "10.48550/arXiv.2106.09685").
+func ExtractDOI(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	path, err := url.PathUnescape(u.EscapedPath())
+	if err != nil {
+		path = u.Path
+	}
+
+	if host := strings.ToLower(u.Hostname()); host == "arxiv.org" || strings.HasSuffix(host, ".arxiv.org") {
+		if m := arxivIDPattern.FindStringSubmatch(path); m != nil {
+			return "10.48550/arXiv." + m[1]
+		}
+	}
+
+	doi := doiPattern.FindString(path)
+	if doi == "" {
+		// Some sites carry the DOI in a query parameter, e.g., "?doi=10.1000/xyz".
+		doi = doiPattern.FindString(u.Query().Get("doi"))
+	}
+	for trimmed := true; trimmed && doi != ""; {
+		trimmed = false
+		for _, suffix := range doiURLSuffixes {
+			if strings.HasSuffix(strings.ToLower(doi), suffix) {
+				doi = doi[:len(doi)-len(suffix)]
+				trimmed = true
+			}
+		}
+	}
+	return strings.TrimRight(doi, "/.")
+}
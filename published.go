@@ -0,0 +1,102 @@
+package search
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// publishedMetaNames are the <meta> names and properties that hold a page's publication date,
+// most specific first.
+var publishedMetaNames = []string{
+	"article:published_time",
+	"og:published_time",
+	"citation_publication_date",
+	"dc.date.issued",
+	"dcterms.issued",
+	"dc.date",
+	"date",
+	"pubdate",
+	"publish-date",
+	"sailthru.date",
+}
+
+// publishedLayouts are the date formats accepted in publication dates.
+var publishedLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+// publishedAt returns when a page was published: from its <meta> tags (e.g.,
+// article:published_time), its JSON-LD datePublished, or, failing those, the Last-Modified
+// response header. It returns nil if no date is found. header may be nil.
+func publishedAt(meta *pageMetadata, header http.Header) *time.Time {
+	if t := parsePublished(meta.metas["article:published_time"]); t != nil {
+		return t
+	}
+	for _, script := range meta.ldScripts {
+		var data any
+		if json.Unmarshal([]byte(script), &data) == nil {
+			if t := parsePublished(findJSONLDString(data, "datePublished")); t != nil {
+				return t
+			}
+		}
+	}
+	for _, name := range publishedMetaNames[1:] {
+		if t := parsePublished(meta.metas[name]); t != nil {
+			return t
+		}
+	}
+	if header != nil {
+		if t, err := http.ParseTime(header.Get("Last-Modified")); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// findJSONLDString returns the first string value of key found in JSON-LD data,
+// searching nested objects and arrays (such as @graph) depth-first.
+func findJSONLDString(data any, key string) string {
+	switch v := data.(type) {
+	case map[string]any:
+		if s, ok := v[key].(string); ok && s != "" {
+			return s
+		}
+		for _, child := range v {
+			if s := findJSONLDString(child, key); s != "" {
+				return s
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if s := findJSONLDString(child, key); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// parsePublished parses a publication date in one of publishedLayouts. It returns nil if s is
+// empty or not a recognized date.
+func parsePublished(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	for _, layout := range publishedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SourceCategory is a kind of source that a QueryBuilder can prefer or avoid.
+// Values other than the predefined constants are used verbatim as a description of the sources.
+type SourceCategory string
+
+// Constants for SourceCategory
+const (
+	SourceAcademic   SourceCategory = "academic"
+	SourceGovernment SourceCategory = "government"
+	SourceOfficial   SourceCategory = "official"
+	SourceNews       SourceCategory = "news"
+	SourceReference  SourceCategory = "reference"
+	SourceSocial     SourceCategory = "social"
+	SourceForums     SourceCategory = "forums"
+	SourceAffiliate  SourceCategory = "affiliate"
+)
+
+// sourceCategoryDescriptions describe the predefined source categories to the model.
+var sourceCategoryDescriptions = map[SourceCategory]string{
+	SourceAcademic:   "academic sources (peer-reviewed journals, university publications, preprint servers)",
+	SourceGovernment: "government and intergovernmental sources (official statistics, legislation, regulators)",
+	SourceOfficial:   "primary sources (the official website, documentation, or announcements of the organization or product concerned)",
+	SourceNews:       "established news organizations",
+	SourceReference:  "reference works (encyclopedias, dictionaries, standards bodies)",
+	SourceSocial:     "social media posts and personal blogs",
+	SourceForums:     "forums and Q&A sites",
+	SourceAffiliate:  "affiliate marketing, sponsored content, and review aggregator sites",
+}
+
+// describe returns the description of c used in prompts.
+func (c SourceCategory) describe() string {
+	if d, ok := sourceCategoryDescriptions[c]; ok {
+		return d
+	}
+	return strings.TrimSpace(string(c))
+}
+
+// QueryBuilder composes a question with source constraints into GenerationParams, compiling the
+// constraints into consistent search guidance instead of hand-written constraint blocks:
+//
+//	params := search.NewQuery("Is intermittent fasting effective for weight loss?").
+//		PreferSources(search.SourceAcademic, search.SourceGovernment).
+//		AvoidSources(search.SourceSocial, search.SourceAffiliate).
+//		RequireCitations().
+//		Freshness(time.Now().AddDate(-2, 0, 0)).
+//		Params()
+//
+// Its methods modify and return the builder, so calls can be chained.
+type QueryBuilder struct {
+	question         string
+	prefer           []SourceCategory
+	avoid            []SourceCategory
+	requireCitations bool
+	since            time.Time
+}
+
+// NewQuery starts building a query for question.
+func NewQuery(question string) *QueryBuilder {
+	return &QueryBuilder{question: question}
+}
+
+// PreferSources asks the model to favor sources of the given categories.
+func (b *QueryBuilder) PreferSources(categories ...SourceCategory) *QueryBuilder {
+	b.prefer = appendCategories(b.prefer, categories)
+	return b
+}
+
+// AvoidSources asks the model not to rely on sources of the given categories.
+func (b *QueryBuilder) AvoidSources(categories ...SourceCategory) *QueryBuilder {
+	b.avoid = appendCategories(b.avoid, categories)
+	return b
+}
+
+// RequireCitations asks the model to support every factual claim with a cited source.
+func (b *QueryBuilder) RequireCitations() *QueryBuilder {
+	b.requireCitations = true
+	return b
+}
+
+// Freshness asks the model to prefer sources published on or after since.
+func (b *QueryBuilder) Freshness(since time.Time) *QueryBuilder {
+	b.since = since
+	return b
+}
+
+// Guidance returns the search guidance compiled from the builder's constraints,
+// one instruction per line. It is empty if no constraint is set.
+func (b *QueryBuilder) Guidance() string {
+	var lines []string
+	if len(b.prefer) > 0 {
+		lines = append(lines, "Prefer "+describeCategories(b.prefer)+".")
+	}
+	if len(b.avoid) > 0 {
+		lines = append(lines, "Avoid relying on "+describeCategories(b.avoid)+"; use them only if no better source exists.")
+	}
+	if !b.since.IsZero() {
+		lines = append(lines, fmt.Sprintf("Prefer sources published on or after %s, and say so if only older sources are available.",
+			b.since.Format("January 2, 2006")))
+	}
+	if b.requireCitations {
+		lines = append(lines, "Support every factual claim with a cited source, and leave out claims that cannot be supported by search results.")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Params returns GenerationParams with the question as Prompt and the compiled constraints
+// as SearchGuidance. The returned params can be further customized before use.
+func (b *QueryBuilder) Params() *GenerationParams {
+	return &GenerationParams{
+		Prompt:         b.question,
+		SearchGuidance: b.Guidance(),
+	}
+}
+
+// appendCategories appends the categories not already in s, ignoring empty ones.
+func appendCategories(s, categories []SourceCategory) []SourceCategory {
+	for _, c := range categories {
+		if strings.TrimSpace(string(c)) == "" {
+			continue
+		}
+		dup := false
+		for _, existing := range s {
+			if existing == c {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			s = append(s, c)
+		}
+	}
+	return s
+}
+
+// describeCategories joins the descriptions of categories into an English list.
+func describeCategories(categories []SourceCategory) string {
+	descs := make([]string, len(categories))
+	for i, c := range categories {
+		descs[i] = c.describe()
+	}
+	switch len(descs) {
+	case 1:
+		return descs[0]
+	case 2:
+		return descs[0] + " and " + descs[1]
+	default:
+		return strings.Join(descs[:len(descs)-1], ", ") + ", and " + descs[len(descs)-1]
+	}
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket limiting the rate of API calls made by a Client.
+// It is shared by every goroutine using the client.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // bucket capacity
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a token bucket allowing rps calls per second with the given burst.
+// It returns nil if rps is not positive, which disables limiting.
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until a token is available or ctx is done.
+// A nil rateLimiter never blocks.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		delay := l.reserve()
+		if delay == 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes a token if one is available and returns 0, or returns how long to wait
+// before a token will be available.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"encoding/base64"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// redirectTargetParams are the query parameters redirect services commonly carry their target in.
+var redirectTargetParams = []string{"url", "u", "q", "target", "dest", "destination", "redirect", "redirect_url"}
+
+// embeddedURLPattern matches an absolute HTTP(S) URL embedded in decoded data.
+var embeddedURLPattern = regexp.MustCompile(`https?://[A-Za-z0-9\-._~:/?#\[\]@!$&'()*+,;=%]+`)
+
+// decodeRedirectURL extracts the target of a grounding redirect URL without a network request. It
+// recognizes targets carried in a query parameter (e.g., "?url=https://..."), percent-encoded in
+// the path, or base64-encoded in the last path segment. It returns false if rawURL is not a
+// grounding redirect URL, since the URL of a cited page may itself contain another URL (e.g., a
+// search page's "?q=https://..."), or if it encodes no target, as is the case for most grounding
+// redirect URLs, whose tokens are opaque.
+func decodeRedirectURL(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || strings.ToLower(u.Hostname()) != groundingRedirectHost {
+		return "", false
+	}
+
+	query := u.Query()
+	for _, name := range redirectTargetParams {
+		if target, ok := absoluteHTTPURL(query.Get(name)); ok {
+			return target, true
+		}
+	}
+
+	// A percent-encoded URL in the path, e.g. /redirect/https%3A%2F%2Fexample.com%2Fpage.
+	if p, err := url.PathUnescape(u.EscapedPath()); err == nil {
+		if i := strings.Index(p, "http"); i >= 0 {
+			if target, ok := absoluteHTTPURL(p[i:]); ok {
+				return target, true
+			}
+		}
+	}
+
+	// A base64-encoded URL, or a token embedding one, as the last path segment.
+	if seg := path.Base(u.Path); len(seg) > 8 {
+		for _, enc := range []*base64.Encoding{base64.RawURLEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.StdEncoding} {
+			b, err := enc.DecodeString(seg)
+			if err != nil {
+				continue
+			}
+			if target, ok := absoluteHTTPURL(embeddedURLPattern.FindString(string(b))); ok {
+				return target, true
+			}
+			break
+		}
+	}
+	return "", false
+}
+
+// absoluteHTTPURL returns s if it is an absolute HTTP(S) URL with a host.
+func absoluteHTTPURL(s string) (string, bool) {
+	if s == "" {
+		return "", false
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false
+	}
+	return s, true
+}
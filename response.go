@@ -0,0 +1,83 @@
+package search
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Domains returns the distinct, sorted domains of the response's grounding attributions.
+// The attribution's Domain is used when set; otherwise the host of its URL.
+func (r *Response) Domains() []string {
+	if r == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var domains []string
+	for _, attr := range r.GroundingAttributions {
+		d := attr.Domain
+		if d == "" {
+			d = hostOf(attr.URL)
+		}
+		if d == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// Summary returns a compact, single-line description of the response suitable for logs.
+// It contains only lengths, counts, source domains, and token usage, never the generated
+// text or the prompt, so it is safe to log when answers may contain personal data.
+func (r *Response) Summary() string {
+	if r == nil {
+		return "<nil response>"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "text_len=%d attributions=%d domains=[%s]",
+		len(r.GeneratedText), len(r.GroundingAttributions), strings.Join(r.Domains(), ","))
+	if len(r.FunctionCalls) > 0 {
+		fmt.Fprintf(&b, " function_calls=%d", len(r.FunctionCalls))
+	}
+	if prompt, output, total, ok := r.tokenCounts(); ok {
+		fmt.Fprintf(&b, " prompt_tokens=%d output_tokens=%d total_tokens=%d", prompt, output, total)
+	}
+	return b.String()
+}
+
+// LogValue implements slog.LogValuer. Like Summary, it logs only lengths, counts,
+// source domains, and token usage, never the generated text.
+func (r *Response) LogValue() slog.Value {
+	if r == nil {
+		return slog.StringValue("<nil response>")
+	}
+	attrs := []slog.Attr{
+		slog.Int("text_len", len(r.GeneratedText)),
+		slog.Int("attributions", len(r.GroundingAttributions)),
+		slog.Any("domains", r.Domains()),
+	}
+	if len(r.FunctionCalls) > 0 {
+		attrs = append(attrs, slog.Int("function_calls", len(r.FunctionCalls)))
+	}
+	if prompt, output, total, ok := r.tokenCounts(); ok {
+		attrs = append(attrs, slog.Group("usage",
+			slog.Int("prompt_tokens", int(prompt)),
+			slog.Int("output_tokens", int(output)),
+			slog.Int("total_tokens", int(total)),
+		))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// tokenCounts returns the token usage reported by the API, if any.
+func (r *Response) tokenCounts() (prompt, output, total int32, ok bool) {
+	if r.RawResponse == nil || r.RawResponse.UsageMetadata == nil {
+		return 0, 0, 0, false
+	}
+	u := r.RawResponse.UsageMetadata
+	return u.PromptTokenCount, u.CandidatesTokenCount + u.ThoughtsTokenCount, u.TotalTokenCount, true
+}
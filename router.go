@@ -0,0 +1,155 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"google.golang.org/genai"
+)
+
+// QueryComplexity is the complexity class a model router assigns to a query.
+type QueryComplexity string
+
+// Constants for QueryComplexity
+const (
+	// QueryComplexitySimple is a query a fast model can answer well, e.g., a single fact lookup.
+	QueryComplexitySimple QueryComplexity = "simple"
+	// QueryComplexityComplex is a query that benefits from a stronger model, e.g., one that needs
+	// several searches to be combined, compared, or reasoned about.
+	QueryComplexityComplex QueryComplexity = "complex"
+)
+
+// QueryClassifier decides the complexity of a request.
+type QueryClassifier func(ctx context.Context, params *GenerationParams) (QueryComplexity, error)
+
+// ModelRoutingPolicy configures automatic model selection by query complexity (see WithModelRouter).
+type ModelRoutingPolicy struct {
+	// SimpleModel is used for simple queries. Defaults to DefaultModelName.
+	SimpleModel string
+
+	// ComplexModel is used for complex queries. Defaults to DefaultComplexModelName.
+	ComplexModel string
+
+	// PreflightModel, if set, classifies each query with a call to this (cheap) model instead of
+	// the built-in heuristics. The preflight call adds latency and cost to every request.
+	PreflightModel string
+
+	// Classifier, if set, replaces the built-in classification entirely.
+	Classifier QueryClassifier
+}
+
+// complexQueryWords is the number of words from which the heuristics consider a query complex.
+const complexQueryWords = 40
+
+// multiHopIndicators are phrases that suggest a query needs several searches to be combined.
+var multiHopIndicators = []string{
+	"compare", "comparison", " vs ", " vs. ", "versus", "difference between", "differences between",
+	"pros and cons", "trade-off", "tradeoff", "advantages and disadvantages",
+	"why ", "how does", "how do", "how did", "impact of", "effect of", "affect",
+	"analyze", "analyse", "evaluate", "explain", "step by step", "in relation to",
+	"relationship between", "timeline", "history of", "and then", "before and after",
+}
+
+// routeModel returns the model a request with params is routed to under the client's policy.
+// It returns an empty string if the request is not routed, i.e., no router is configured or
+// the request names a model explicitly.
+func (c *Client) routeModel(ctx context.Context, params *GenerationParams) (string, error) {
+	policy := c.config.ModelRouter
+	if policy == nil || params == nil || params.ModelName != "" {
+		return "", nil
+	}
+
+	classify := policy.Classifier
+	if classify == nil {
+		if policy.PreflightModel != "" {
+			classify = c.classifyWithPreflight
+		} else {
+			classify = classifyHeuristically
+		}
+	}
+	complexity, err := classify(ctx, params)
+	if err != nil {
+		return "", ierrors.Wrap(err, "failed to classify query for model routing")
+	}
+
+	switch complexity {
+	case QueryComplexitySimple:
+		return policy.SimpleModel, nil
+	case QueryComplexityComplex:
+		return policy.ComplexModel, nil
+	default:
+		return "", ierrors.Wrapf(ErrInvalidParameter, "unknown query complexity %q", complexity)
+	}
+}
+
+// classifyHeuristically classifies a request by its length, attachments, and phrases that
+// indicate multi-hop questions.
+func classifyHeuristically(_ context.Context, params *GenerationParams) (QueryComplexity, error) {
+	if len(params.Files) > 0 || len(params.Images) > 0 || len(params.ImageURIs) > 0 || params.Audio != nil {
+		return QueryComplexityComplex, nil
+	}
+
+	question := strings.ToLower(params.question())
+	if len(strings.Fields(question)) >= complexQueryWords || strings.Count(question, "?") > 1 {
+		return QueryComplexityComplex, nil
+	}
+	padded := " " + strings.Join(strings.Fields(question), " ") + " "
+	for _, indicator := range multiHopIndicators {
+		if strings.Contains(padded, indicator) {
+			return QueryComplexityComplex, nil
+		}
+	}
+	return QueryComplexitySimple, nil
+}
+
+// classifyWithPreflight classifies a request's question with the policy's preflight model.
+func (c *Client) classifyWithPreflight(ctx context.Context, params *GenerationParams) (QueryComplexity, error) {
+	temperature := float32(0)
+	config := &genai.GenerateContentConfig{
+		Temperature:      &temperature,
+		ResponseMIMEType: "application/json",
+		ResponseSchema: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"complexity": {Type: genai.TypeString, Enum: []string{string(QueryComplexitySimple), string(QueryComplexityComplex)}},
+			},
+			Required: []string{"complexity"},
+		},
+		SafetySettings: c.defaultGenContentConfig.SafetySettings,
+	}
+
+	contents := []*genai.Content{
+		genai.NewContentFromText(buildRoutingPrompt(params.question()), genai.RoleUser),
+	}
+	if err := c.rateLimiter.wait(ctx); err != nil {
+		return "", err
+	}
+	genaiClient, record := c.generationClient()
+	resp, err := genaiClient.Models.GenerateContent(ctx, c.config.ModelRouter.PreflightModel, contents, config)
+	record(err)
+	if err != nil {
+		return "", newAPIErrorFromSDK(err, "routing preflight failed")
+	}
+
+	var verdict struct {
+		Complexity QueryComplexity `json:"complexity"`
+	}
+	if err := json.Unmarshal([]byte(resp.Text()), &verdict); err != nil {
+		return "", ierrors.Wrap(err, "failed to parse routing preflight result")
+	}
+	return verdict.Complexity, nil
+}
+
+// buildRoutingPrompt builds the classification prompt for the routing preflight model.
+func buildRoutingPrompt(question string) string {
+	return `You route questions for a web search assistant to either a fast or a strong model.
+Classify the user query below as "simple" if it can be answered from one or two searches
+(e.g., a single fact, definition, date, or current status), or as "complex" if answering it
+requires combining, comparing, or reasoning over several sources. Do not answer the query itself.
+
+<user_query>
+` + question + `
+</user_query>`
+}
@@ -0,0 +1,37 @@
+package search
+
+// safetyPresetCategories are the harm categories covered by the safety presets.
+var safetyPresetCategories = []HarmCategory{
+	HarmCategoryHarassment,
+	HarmCategoryHateSpeech,
+	HarmCategorySexuallyExplicit,
+	HarmCategoryDangerousContent,
+}
+
+// newSafetyPreset returns a safety setting with threshold for every preset category.
+func newSafetyPreset(threshold HarmBlockThreshold) []*SafetySetting {
+	settings := make([]*SafetySetting, len(safetyPresetCategories))
+	for i, c := range safetyPresetCategories {
+		settings[i] = &SafetySetting{Category: c, Threshold: threshold}
+	}
+	return settings
+}
+
+// SafetyPresetStrict returns safety settings that block content with a low or higher
+// probability of harm in every category. Use it with WithDefaultSafetySettings or
+// GenerationParams.SafetySettings. Each call returns a new slice, so it can be modified freely.
+func SafetyPresetStrict() []*SafetySetting {
+	return newSafetyPreset(HarmBlockThresholdBlockLow)
+}
+
+// SafetyPresetBalanced returns safety settings that block content with a medium or higher
+// probability of harm in every category.
+func SafetyPresetBalanced() []*SafetySetting {
+	return newSafetyPreset(HarmBlockThresholdBlockMedium)
+}
+
+// SafetyPresetNone returns safety settings that block no content in any category.
+// Content is still rated (see Response.SafetyRatings).
+func SafetyPresetNone() []*SafetySetting {
+	return newSafetyPreset(HarmBlockThresholdBlockNone)
+}
@@ -0,0 +1,273 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// SearchScope is a named grounded-search policy for a vertical (e.g., "legal-jp"), registered on
+// the client with WithSearchScopes and selected per request with GenerationParams.Scope.
+type SearchScope struct {
+	// Name identifies the scope. It must be unique within a client.
+	Name string `json:"name"`
+
+	// AllowedDomains restricts the cited sources to these domains and their subdomains
+	// (e.g., "gov.uk", "courts.go.jp"). The model is instructed to use only these domains,
+	// and attributions from other domains are removed from the response. URL resolution is
+	// enabled for requests with the scope, so that the domains of the sources are known;
+	// attributions whose domain still cannot be determined are removed. If empty, any domain
+	// is allowed.
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+
+	// Language is the language the answer should be written in (e.g., "Japanese", "ja").
+	Language string `json:"language,omitempty"`
+
+	// Recency asks the model to prefer sources published within this period. Zero means no preference.
+	Recency time.Duration `json:"recency,omitempty"`
+
+	// CitationStyle names the citation style that applications should use to render the sources of
+	// responses produced with this scope (e.g., "apa", "bluebook"). It is not interpreted by the client.
+	CitationStyle string `json:"citation_style,omitempty"`
+
+	// Guidance is additional free-form search guidance for the scope.
+	Guidance string `json:"guidance,omitempty"`
+}
+
+// validateSearchScopes checks that every scope is named and that no name is registered twice.
+func validateSearchScopes(scopes []SearchScope, registered []SearchScope) error {
+	seen := make(map[string]bool, len(scopes)+len(registered))
+	for _, s := range registered {
+		seen[s.Name] = true
+	}
+	for _, s := range scopes {
+		if strings.TrimSpace(s.Name) == "" {
+			return ierrors.Wrap(ErrInvalidParameter, "search scope must have a name")
+		}
+		if seen[s.Name] {
+			return ierrors.Wrapf(ErrInvalidParameter, "search scope %q is registered more than once", s.Name)
+		}
+		if s.Recency < 0 {
+			return ierrors.Wrapf(ErrInvalidParameter, "recency of search scope %q cannot be negative, got %v", s.Name, s.Recency)
+		}
+		for _, d := range s.AllowedDomains {
+			if normalizeDomain(d) == "" {
+				return ierrors.Wrapf(ErrInvalidParameter, "search scope %q has an empty allowed domain", s.Name)
+			}
+		}
+		seen[s.Name] = true
+	}
+	return nil
+}
+
+// SearchScope returns the scope registered under name and whether it exists.
+func (c *Client) SearchScope(name string) (SearchScope, bool) {
+	s, ok := c.scopes[name]
+	return s, ok
+}
+
+// lookupScope returns the scope selected by params, or nil if none is selected.
+func (c *Client) lookupScope(params *GenerationParams) (*SearchScope, error) {
+	if params == nil || params.Scope == "" {
+		return nil, nil
+	}
+	s, ok := c.scopes[params.Scope]
+	if !ok {
+		return nil, ierrors.Wrapf(ErrInvalidParameter, "unknown search scope %q", params.Scope)
+	}
+	return &s, nil
+}
+
+// guidance returns the search guidance derived from the scope's policy.
+func (s *SearchScope) guidance() string {
+	var lines []string
+	if len(s.AllowedDomains) > 0 {
+		domains := make([]string, len(s.AllowedDomains))
+		for i, d := range s.AllowedDomains {
+			domains[i] = normalizeDomain(d)
+		}
+		lines = append(lines, fmt.Sprintf("Use and cite only sources from these domains (including their subdomains): %s.",
+			strings.Join(domains, ", ")))
+	}
+	if s.Recency > 0 {
+		lines = append(lines, fmt.Sprintf("Prefer sources published within the last %s.", formatPeriod(s.Recency)))
+	}
+	if s.Language != "" {
+		lines = append(lines, fmt.Sprintf("Write the answer in %s.", s.Language))
+	}
+	if g := strings.TrimSpace(s.Guidance); g != "" {
+		lines = append(lines, g)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// applyScope returns a copy of params whose SearchGuidance includes the scope's guidance.
+func applyScope(params *GenerationParams, s *SearchScope) *GenerationParams {
+	scoped := *params
+	if g := s.guidance(); g != "" {
+		if strings.TrimSpace(scoped.SearchGuidance) != "" {
+			g = scoped.SearchGuidance + "\n" + g
+		}
+		scoped.SearchGuidance = g
+	}
+	return &scoped
+}
+
+// needsResolvedDomains reports whether the attributions of a request made with params and
+// scope are filtered, split, or flagged by domain. This needs the origin URLs of the sources,
+// since the Gemini API reports no domain for grounding redirect URLs, so URL resolution is
+// enabled for such requests.
+func (c *Client) needsResolvedDomains(params *GenerationParams, scope *SearchScope) bool {
+	allowed, blocked := c.domainFilters(params)
+	return len(allowed) > 0 || len(blocked) > 0 || len(params.RestrictToSites) > 0 || len(params.ExcludeSites) > 0 ||
+		(scope != nil && len(scope.AllowedDomains) > 0)
+}
+
+// domainFilters returns the allowed and blocked domains of a request made with params: the
+// client-level ones (see WithAllowedDomains and WithBlockedDomains) unless params overrides them.
+func (c *Client) domainFilters(params *GenerationParams) (allowed, blocked []string) {
+	allowed, blocked = c.config.AllowedDomains, c.config.BlockedDomains
+	if params.AllowedDomains != nil {
+		allowed = params.AllowedDomains
+	}
+	if params.BlockedDomains != nil {
+		blocked = params.BlockedDomains
+	}
+	return allowed, blocked
+}
+
+// filterAttributions applies the confidence, domain, and site filters of a request made with
+// params and scope to attrs. It returns the kept attributions and those moved out by
+// GenerationParams.ExcludeSites.
+func (c *Client) filterAttributions(params *GenerationParams, scope *SearchScope, attrs []GroundingAttribution) (kept, excluded []GroundingAttribution) {
+	minConfidence := c.config.MinGroundingConfidence
+	if params.MinConfidence != nil {
+		minConfidence = *params.MinConfidence
+	}
+	if minConfidence > 0 {
+		attrs = filterByConfidence(attrs, minConfidence)
+	}
+	allowed, blocked := c.domainFilters(params)
+	attrs = filterDomains(attrs, allowed, blocked)
+	if len(params.ExcludeSites) > 0 {
+		attrs, excluded = splitExcludedAttributions(attrs, params.ExcludeSites)
+	}
+	if len(params.RestrictToSites) > 0 {
+		markOffSite(attrs, params.RestrictToSites)
+	}
+	if scope != nil {
+		attrs = scope.filterAllowedDomains(attrs)
+	}
+	return attrs, excluded
+}
+
+// filterAllowedDomains removes the attributions that are not from one of the scope's allowed domains.
+func (s *SearchScope) filterAllowedDomains(attrs []GroundingAttribution) []GroundingAttribution {
+	return filterDomains(attrs, s.AllowedDomains, nil)
+}
+
+// filterDomains removes the attributions that are not from one of the allowed domains (if any are
+// given) or that are from one of the blocked domains. Domains match their subdomains as well.
+// Attributions whose domain cannot be determined are removed only if an allow-list is given.
+func filterDomains(attrs []GroundingAttribution, allowed, blocked []string) []GroundingAttribution {
+	if len(allowed) == 0 && len(blocked) == 0 {
+		return attrs
+	}
+	kept := attrs[:0:0]
+	for _, attr := range attrs {
+		domain := attributionDomain(attr)
+		if len(allowed) > 0 && (domain == "" || !matchesAnyDomain(domain, allowed)) {
+			continue
+		}
+		if domain != "" && matchesAnyDomain(domain, blocked) {
+			continue
+		}
+		kept = append(kept, attr)
+	}
+	return kept
+}
+
+// matchesAnyDomain reports whether host is one of domains or a subdomain of one of them.
+func matchesAnyDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		if matchesDomain(host, normalizeDomain(d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDomains checks that none of domains is empty. kind names the list in error messages.
+func validateDomains(kind string, domains []string) error {
+	for _, d := range domains {
+		if normalizeDomain(d) == "" {
+			return ierrors.Wrapf(ErrInvalidParameter, "%s domains cannot contain an empty domain", kind)
+		}
+	}
+	return nil
+}
+
+// markOffSite sets OffSite on the attributions whose domain is not one of sites or their
+// subdomains, including those whose domain cannot be determined.
+func markOffSite(attrs []GroundingAttribution, sites []string) {
+	for i := range attrs {
+		domain := attributionDomain(attrs[i])
+		attrs[i].OffSite = domain == "" || !matchesAnyDomain(domain, sites)
+	}
+}
+
+// splitExcludedAttributions separates the attributions from sites or their subdomains from
+// the others. Attributions whose domain cannot be determined, e.g., because URL resolution
+// failed, are kept.
+func splitExcludedAttributions(attrs []GroundingAttribution, sites []string) (kept, excluded []GroundingAttribution) {
+	for _, attr := range attrs {
+		if domain := attributionDomain(attr); domain != "" && matchesAnyDomain(domain, sites) {
+			excluded = append(excluded, attr)
+		} else {
+			kept = append(kept, attr)
+		}
+	}
+	return kept, excluded
+}
+
+// attributionDomain returns the domain of the source of attr: the host of its URL, or its
+// reported Domain while the URL still points at the grounding redirect service.
+func attributionDomain(attr GroundingAttribution) string {
+	host := hostOf(attr.URL)
+	if host == "" || host == groundingRedirectHost {
+		return normalizeDomain(attr.Domain)
+	}
+	return host
+}
+
+// normalizeDomain lower-cases d and strips surrounding whitespace, a leading "*." or ".", and a trailing dot.
+func normalizeDomain(d string) string {
+	d = strings.ToLower(strings.TrimSpace(d))
+	d = strings.TrimPrefix(d, "*.")
+	d = strings.TrimPrefix(d, ".")
+	return strings.TrimSuffix(d, ".")
+}
+
+// matchesDomain reports whether host is domain or one of its subdomains.
+func matchesDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// formatPeriod formats d for use in a prompt, in days when it is a whole number of days.
+func formatPeriod(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d%day == 0 && d == day:
+		return "day"
+	case d%day == 0:
+		return fmt.Sprintf("%d days", d/day)
+	case d%time.Hour == 0 && d == time.Hour:
+		return "hour"
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,283 @@
+/*
+Package searchtest provides a scriptable fake of the grounded search client for testing
+applications without API keys or network access.
+
+A Fake answers requests with canned responses or programmed errors, optionally after an
+artificial delay, and records every call. It can be used directly wherever an interface with
+the client's generation methods is accepted (such as eval.Generator), or installed into a real
+*search.Client as its innermost interceptor, so that the application's own interceptors still
+run while the API is never contacted.
+
+Basic Usage:
+
+	fake := searchtest.NewFake()
+	fake.RespondTo("capital of France", searchtest.TextResponse("Paris is the capital of France.",
+		search.GroundingAttribution{Title: "Paris", URL: "https://en.wikipedia.org/wiki/Paris"}))
+	fake.FailNext(searchtest.QuotaError())
+
+	client, err := searchtest.NewClient(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Exercise the code under test with client, then inspect fake.Calls().
+*/
+package searchtest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	search "github.com/cnosuke/go-gemini-grounded-search"
+	"google.golang.org/grpc/codes"
+)
+
+// fakeAPIKey is the API key of clients created by NewClient. It is never sent anywhere.
+const fakeAPIKey = "searchtest-fake-api-key"
+
+// Call records one request received by a Fake.
+type Call struct {
+	// Params are the parameters of the request. Prompt-only calls have just Prompt set.
+	Params search.GenerationParams
+
+	// Response is the response returned, or nil if the call failed.
+	Response *search.Response
+
+	// Err is the error returned, if any.
+	Err error
+
+	// Time is when the call was received.
+	Time time.Time
+}
+
+// rule maps requests whose prompt contains substring to a response or an error.
+type rule struct {
+	substring string
+	resp      *search.Response
+	err       error
+}
+
+// Fake is a scriptable stand-in for *search.Client. Responses are chosen in this order: the
+// next queued error (FailNext), the first rule whose substring the prompt contains (RespondTo,
+// FailOn), then the default response (Respond). Without any of them, calls fail with
+// search.ErrNoContentGenerated. A Fake is safe for concurrent use.
+type Fake struct {
+	mu       sync.Mutex
+	latency  time.Duration
+	fallback *search.Response
+	rules    []rule
+	failures []error
+	calls    []Call
+}
+
+// NewFake creates a Fake without any programmed responses.
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Respond sets the response returned to requests that no other rule matches.
+func (f *Fake) Respond(resp *search.Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fallback = resp
+	return f
+}
+
+// RespondTo returns resp to requests whose prompt contains substring (case-insensitively).
+func (f *Fake) RespondTo(substring string, resp *search.Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, rule{substring: strings.ToLower(substring), resp: resp})
+	return f
+}
+
+// FailOn returns err to requests whose prompt contains substring (case-insensitively).
+func (f *Fake) FailOn(substring string, err error) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, rule{substring: strings.ToLower(substring), err: err})
+	return f
+}
+
+// FailNext makes the next calls fail with errs, one call per error, before any rule applies.
+// This scripts transient failures, e.g., a quota error followed by a success.
+func (f *Fake) FailNext(errs ...error) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, errs...)
+	return f
+}
+
+// WithLatency delays every response by d. The delay ends early if the request's context is done.
+func (f *Fake) WithLatency(d time.Duration) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+	return f
+}
+
+// Calls returns the calls received so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Reset forgets all programmed responses, errors, latency, and recorded calls.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = 0
+	f.fallback = nil
+	f.rules = nil
+	f.failures = nil
+	f.calls = nil
+}
+
+// GenerateGroundedContent answers query like (*search.Client).GenerateGroundedContent.
+func (f *Fake) GenerateGroundedContent(ctx context.Context, query string) (*search.Response, error) {
+	return f.GenerateGroundedContentWithParams(ctx, &search.GenerationParams{Prompt: query})
+}
+
+// GenerateGroundedContentWithParams answers a request like
+// (*search.Client).GenerateGroundedContentWithParams. As a method value, it is a search.GenerateFunc.
+func (f *Fake) GenerateGroundedContentWithParams(ctx context.Context, params *search.GenerationParams) (*search.Response, error) {
+	call := Call{Time: time.Now()}
+	if params != nil {
+		call.Params = *params
+	}
+
+	f.mu.Lock()
+	latency := f.latency
+	resp, err := f.pick(params)
+	f.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			resp, err = nil, ctx.Err()
+		}
+	} else if ctx.Err() != nil {
+		resp, err = nil, ctx.Err()
+	}
+	if resp != nil {
+		resp = cloneResponse(resp)
+	}
+
+	call.Response, call.Err = resp, err
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+	return resp, err
+}
+
+// pick chooses the outcome of a request. f.mu must be held.
+func (f *Fake) pick(params *search.GenerationParams) (*search.Response, error) {
+	if params == nil {
+		return nil, search.ErrInvalidParameter
+	}
+	if len(f.failures) > 0 {
+		err := f.failures[0]
+		f.failures = f.failures[1:]
+		return nil, err
+	}
+	prompt := strings.ToLower(params.Prompt)
+	if params.PromptParts != nil {
+		prompt += "\n" + strings.ToLower(params.PromptParts.Question)
+	}
+	for _, r := range f.rules {
+		if strings.Contains(prompt, r.substring) {
+			return r.resp, r.err
+		}
+	}
+	if f.fallback != nil {
+		return f.fallback, nil
+	}
+	return nil, search.ErrNoContentGenerated
+}
+
+// Interceptor returns an interceptor that answers every request with the Fake instead of
+// calling the API. Install it last, so that interceptors added before it still run; the
+// client's own generation pipeline, including its grounding filters, is skipped.
+func (f *Fake) Interceptor() search.Interceptor {
+	return func(ctx context.Context, params *search.GenerationParams, _ search.GenerateFunc) (*search.Response, error) {
+		return f.GenerateGroundedContentWithParams(ctx, params)
+	}
+}
+
+// NewClient creates a *search.Client whose generation requests are answered by f. opts are
+// applied before the fake's interceptor. Methods that call other API endpoints, such as
+// ListModels or UploadFile, still try to reach the API and should not be used.
+func NewClient(f *Fake, opts ...search.ClientOption) (*search.Client, error) {
+	opts = append(append([]search.ClientOption(nil), opts...), search.WithInterceptor(f.Interceptor()))
+	return search.NewClient(context.Background(), fakeAPIKey, opts...)
+}
+
+// TextResponse builds a response with text and the given sources, as a grounded answer would have.
+func TextResponse(text string, sources ...search.GroundingAttribution) *search.Response {
+	return &search.Response{
+		GeneratedText:         text,
+		GroundingAttributions: sources,
+		FinishReason:          search.FinishReasonStop,
+	}
+}
+
+// QuotaError returns an error like the one the client returns when the API quota is exhausted.
+// search.IsQuotaError reports true for it, and search.GetQuotaError returns its *search.QuotaError.
+func QuotaError() error {
+	return &search.APIError{
+		StatusCode: codes.ResourceExhausted,
+		Message:    "quota exceeded",
+		Err:        &search.QuotaError{},
+	}
+}
+
+// SafetyBlockError returns an error like the one the client returns when safety filters stop
+// generation of a response in the given categories. search.IsContentBlockedError reports true
+// for it, and search.GetBlockedError returns its *search.BlockedError.
+func SafetyBlockError(categories ...search.HarmCategory) error {
+	ratings := make([]search.SafetyRating, len(categories))
+	for i, c := range categories {
+		ratings[i] = search.SafetyRating{Category: c, Probability: search.HarmProbabilityHigh, Blocked: true}
+	}
+	return &search.APIError{
+		StatusCode: codes.FailedPrecondition,
+		Message:    "content generation stopped due to safety filters",
+		Err: &search.BlockedError{
+			Stage:         search.BlockStageCandidate,
+			Reason:        string(search.FinishReasonSafety),
+			SafetyRatings: ratings,
+		},
+	}
+}
+
+// AuthenticationError returns an error like the one the client returns for an invalid API key.
+// search.IsAuthenticationError reports true for it.
+func AuthenticationError() error {
+	return &search.APIError{
+		StatusCode: codes.Unauthenticated,
+		Message:    "API key not valid",
+	}
+}
+
+// ServerError returns an error like the one the client returns when the API is unavailable.
+// search.IsServerError reports true for it.
+func ServerError() error {
+	return &search.APIError{
+		StatusCode: codes.Unavailable,
+		Message:    "service unavailable",
+	}
+}
+
+// cloneResponse returns a copy of resp whose slices can be modified by the caller without
+// affecting the programmed response.
+func cloneResponse(resp *search.Response) *search.Response {
+	c := *resp
+	c.GroundingAttributions = append([]search.GroundingAttribution(nil), resp.GroundingAttributions...)
+	c.SearchSuggestions = append([]string(nil), resp.SearchSuggestions...)
+	return &c
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// pageMetadata is the metadata declared in the <head> of a page.
+type pageMetadata struct {
+	// metas maps lower-cased <meta> names and properties to their content.
+	// Only the first occurrence of each is kept.
+	metas map[string]string
+
+	// links are the page's <link> elements.
+	links []htmlLink
+
+	// ldScripts are the contents of the page's JSON-LD scripts.
+	ldScripts []string
+}
+
+// htmlLink is a <link> element.
+type htmlLink struct {
+	rel  []string // lower-cased link types
+	href string
+}
+
+// newPageMetadata collects the metadata of doc. doc may be nil.
+func newPageMetadata(doc *html.Node) *pageMetadata {
+	meta := &pageMetadata{metas: make(map[string]string)}
+	if doc == nil {
+		return meta
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Meta:
+				key := strings.ToLower(htmlAttr(n, "property"))
+				if key == "" {
+					key = strings.ToLower(htmlAttr(n, "name"))
+				}
+				if _, seen := meta.metas[key]; key != "" && !seen {
+					meta.metas[key] = strings.TrimSpace(htmlAttr(n, "content"))
+				}
+			case atom.Link:
+				meta.links = append(meta.links, htmlLink{
+					rel:  strings.Fields(strings.ToLower(htmlAttr(n, "rel"))),
+					href: strings.TrimSpace(htmlAttr(n, "href")),
+				})
+			case atom.Script:
+				if strings.EqualFold(htmlAttr(n, "type"), "application/ld+json") {
+					meta.ldScripts = append(meta.ldScripts, textContent(n))
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return meta
+}
+
+// link returns the href of the first <link> with the link type rel, resolved against base.
+func (m *pageMetadata) link(base *url.URL, rel string) string {
+	for _, l := range m.links {
+		for _, r := range l.rel {
+			if r == rel && l.href != "" {
+				return resolveReference(base, l.href)
+			}
+		}
+	}
+	return ""
+}
+
+// favicon returns the URL of the page's icon: its declared icon, or /favicon.ico on its host.
+func (m *pageMetadata) favicon(base *url.URL) string {
+	for _, rel := range []string{"icon", "apple-touch-icon"} {
+		if href := m.link(base, rel); href != "" {
+			return href
+		}
+	}
+	if base == nil || base.Host == "" {
+		return ""
+	}
+	return (&url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/favicon.ico"}).String()
+}
+
+// resolveReference resolves ref against base. It returns an empty string if ref is invalid
+// or cannot be made absolute.
+func resolveReference(base *url.URL, ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if !u.IsAbs() {
+		return ""
+	}
+	return u.String()
+}
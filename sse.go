@@ -0,0 +1,128 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// Event names of the Server-Sent Events written by StreamSSE.
+const (
+	// SSEEventText carries a text delta of the answer: {"text": "..."}.
+	SSEEventText = "text"
+	// SSEEventThought carries a thought summary delta: {"text": "..."}. It is only sent if
+	// enabled (see SSEOptions.IncludeThoughts) and thoughts are requested
+	// (see ThinkingConfig.IncludeThoughts).
+	SSEEventThought = "thought"
+	// SSEEventAttributions carries the sources cited since the previous event of its kind, as a
+	// JSON array of GroundingAttributions (see StreamChunk.Attributions). They are provisional;
+	// the SSEEventSources event lists the final sources.
+	SSEEventAttributions = "attributions"
+	// SSEEventRestart means the text and attributions sent so far are superseded and should be
+	// discarded (see StreamChunk.Restart). Its data is an empty object.
+	SSEEventRestart = "restart"
+	// SSEEventSources is the final event of a successful stream. Its data is the JSON array of
+	// the response's GroundingAttributions.
+	SSEEventSources = "sources"
+	// SSEEventError is the final event of a failed stream: {"error": "...", "class": "..."},
+	// where class is the ErrorClass of the error.
+	SSEEventError = "error"
+)
+
+// SSEOptions configures Client.StreamSSE.
+type SSEOptions struct {
+	// IncludeThoughts sends the model's thought summaries as SSEEventThought events. Thoughts are
+	// not meant for end users, so they are only sent if this is set.
+	IncludeThoughts bool
+}
+
+// sseTextData is the data of SSEEventText and SSEEventThought events.
+type sseTextData struct {
+	Text string `json:"text"`
+}
+
+// sseErrorData is the data of SSEEventError events.
+type sseErrorData struct {
+	Error string     `json:"error"`
+	Class ErrorClass `json:"class"`
+}
+
+// StreamSSE streams the answer to params to w as Server-Sent Events, for serving grounded search
+// from a web handler. Text deltas are sent as SSEEventText events as they are generated, newly
+// cited sources as SSEEventAttributions events as they arrive, and the stream ends with an
+// SSEEventSources event listing the final sources, or an SSEEventError event if generation
+// fails. Every event is flushed to the client as it is written. opts may be nil.
+//
+// The request's context bounds generation, so it stops when the client disconnects. The response
+// and error are also returned, e.g., for logging; write errors are returned as well.
+//
+//	http.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+//		client.StreamSSE(w, r, &search.GenerationParams{Prompt: r.URL.Query().Get("q")}, nil)
+//	})
+func (c *Client) StreamSSE(w http.ResponseWriter, r *http.Request, params *GenerationParams, opts *SSEOptions) (*Response, error) {
+	if opts == nil {
+		opts = &SSEOptions{}
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	// Keep reverse proxies such as nginx from buffering the stream.
+	h.Set("X-Accel-Buffering", "no")
+	rc := http.NewResponseController(w)
+
+	resp, err := c.GenerateGroundedContentStreamFunc(r.Context(), params, func(chunk StreamChunk) error {
+		if chunk.Restart {
+			if err := writeSSEEvent(w, rc, SSEEventRestart, struct{}{}); err != nil {
+				return err
+			}
+		}
+		if chunk.Thought != "" && opts.IncludeThoughts {
+			if err := writeSSEEvent(w, rc, SSEEventThought, sseTextData{Text: chunk.Thought}); err != nil {
+				return err
+			}
+		}
+		if chunk.Text != "" {
+			if err := writeSSEEvent(w, rc, SSEEventText, sseTextData{Text: chunk.Text}); err != nil {
+				return err
+			}
+		}
+		if len(chunk.Attributions) > 0 {
+			return writeSSEEvent(w, rc, SSEEventAttributions, chunk.Attributions)
+		}
+		return nil
+	})
+	if err != nil {
+		if writeErr := writeSSEEvent(w, rc, SSEEventError, sseErrorData{Error: err.Error(), Class: ClassifyError(err)}); writeErr != nil {
+			return resp, errors.Join(err, writeErr)
+		}
+		return resp, err
+	}
+
+	sources := resp.GroundingAttributions
+	if sources == nil {
+		sources = []GroundingAttribution{}
+	}
+	if err := writeSSEEvent(w, rc, SSEEventSources, sources); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// writeSSEEvent writes an event with the JSON encoding of data to w and flushes it. Writers that
+// cannot flush are written to without flushing.
+func writeSSEEvent(w http.ResponseWriter, rc *http.ResponseController, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return ierrors.Wrapf(err, "failed to encode %s event", event)
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+		return err
+	}
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File is a Store persisted as a single JSON file. Every operation reads the file, and every
+// write rewrites it atomically, so it suits small, infrequently written data such as the
+// CLI's history rather than high-throughput caches. It is safe for concurrent use within a
+// process, but not across processes.
+type File struct {
+	mu   sync.Mutex
+	path string
+	now  func() time.Time
+}
+
+// NewFile creates a Store persisted at path. The file and its directory are created on the
+// first write; a missing file is treated as an empty store.
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("store: file path cannot be empty")
+	}
+	return &File{path: path, now: time.Now}, nil
+}
+
+// Get implements Store.
+func (f *File) Get(_ context.Context, key string) ([]byte, bool, error) {
+	if key == "" {
+		return nil, false, ErrInvalidKey
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries, err := f.load()
+	if err != nil {
+		return nil, false, err
+	}
+	e, ok := entries[key]
+	if !ok || f.expired(e) {
+		return nil, false, nil
+	}
+	return e.Value, true, nil
+}
+
+// Set implements Store.
+func (f *File) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries, err := f.load()
+	if err != nil {
+		return err
+	}
+	e := Entry{Key: key, Value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.ExpiresAt = f.now().Add(ttl)
+	}
+	entries[key] = e
+	return f.save(entries)
+}
+
+// Delete implements Store.
+func (f *File) Delete(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[key]; !ok {
+		return nil
+	}
+	delete(entries, key)
+	return f.save(entries)
+}
+
+// List implements Store.
+func (f *File) List(_ context.Context, prefix string) ([]Entry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for k, e := range entries {
+		if strings.HasPrefix(k, prefix) && !f.expired(e) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// load reads all entries from the file, including expired ones.
+func (f *File) load() (map[string]Entry, error) {
+	entries := make(map[string]Entry)
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: failed to read %s: %w", f.path, err)
+	}
+	if len(data) == 0 {
+		return entries, nil
+	}
+	var list []Entry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("store: failed to parse %s: %w", f.path, err)
+	}
+	for _, e := range list {
+		entries[e.Key] = e
+	}
+	return entries, nil
+}
+
+// save writes the unexpired entries to a temporary file and renames it over the store file.
+func (f *File) save(entries map[string]Entry) error {
+	list := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if !f.expired(e) {
+			list = append(list, e)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("store: failed to encode entries: %w", err)
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("store: failed to create %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("store: failed to write %s: %w", f.path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("store: failed to write %s: %w", f.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("store: failed to write %s: %w", f.path, err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("store: failed to write %s: %w", f.path, err)
+	}
+	return nil
+}
+
+func (f *File) expired(e Entry) bool {
+	return !e.ExpiresAt.IsZero() && !f.now().Before(e.ExpiresAt)
+}
@@ -0,0 +1,376 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisScanCount is the number of keys Redis is asked to examine per SCAN call.
+const redisScanCount = 256
+
+// redisMaxIdleConns is the number of idle connections Redis keeps for reuse.
+const redisMaxIdleConns = 8
+
+// RedisOptions configures a Redis store.
+type RedisOptions struct {
+	// Username is the ACL user to authenticate as. If empty, Password authenticates the
+	// default user.
+	Username string
+
+	// Password authenticates to the server. No authentication is done if it is empty.
+	Password string
+
+	// DB is the number of the database to select. Defaults to 0.
+	DB int
+
+	// DialTimeout bounds connecting to the server. Defaults to 5 seconds.
+	DialTimeout time.Duration
+}
+
+// Redis is a Store persisted in a Redis server, for sharing data between processes and hosts.
+// Entries expire through Redis' own key expiry. It speaks the Redis protocol directly, so this
+// package does not depend on a client library.
+type Redis struct {
+	addr   string
+	opts   RedisOptions
+	dialer net.Dialer
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+// NewRedis creates a Store persisted in the Redis server at addr (e.g., "localhost:6379").
+// Connections are opened on demand; opts may be nil.
+func NewRedis(addr string, opts *RedisOptions) (*Redis, error) {
+	if addr == "" {
+		return nil, errors.New("store: Redis address cannot be empty")
+	}
+	r := &Redis{addr: addr}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.DialTimeout <= 0 {
+		r.opts.DialTimeout = 5 * time.Second
+	}
+	r.dialer.Timeout = r.opts.DialTimeout
+	return r, nil
+}
+
+// Get implements Store.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if key == "" {
+		return nil, false, ErrInvalidKey
+	}
+	replies, err := r.do(ctx, []string{"GET", key})
+	if err != nil {
+		return nil, false, fmt.Errorf("store: failed to get %q: %w", key, err)
+	}
+	value, ok := replies[0].([]byte)
+	return value, ok, nil
+}
+
+// Set implements Store.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	cmd := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		cmd = append(cmd, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+	if _, err := r.do(ctx, cmd); err != nil {
+		return fmt.Errorf("store: failed to set %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	if _, err := r.do(ctx, []string{"DEL", key}); err != nil {
+		return fmt.Errorf("store: failed to delete %q: %w", key, err)
+	}
+	return nil
+}
+
+// List implements Store. It scans the keyspace for keys with prefix, so listing is slower than
+// with the other stores on large databases.
+func (r *Redis) List(ctx context.Context, prefix string) ([]Entry, error) {
+	var keys []string
+	pattern := redisGlobEscaper.Replace(prefix) + "*"
+	for cursor := "0"; ; {
+		replies, err := r.do(ctx, []string{"SCAN", cursor, "MATCH", pattern, "COUNT", strconv.Itoa(redisScanCount)})
+		if err != nil {
+			return nil, fmt.Errorf("store: failed to list %q: %w", prefix, err)
+		}
+		page, ok := replies[0].([]any)
+		if !ok || len(page) != 2 {
+			return nil, fmt.Errorf("store: failed to list %q: unexpected SCAN reply", prefix)
+		}
+		next, _ := page[0].([]byte)
+		batch, _ := page[1].([]any)
+		for _, k := range batch {
+			if k, ok := k.([]byte); ok {
+				keys = append(keys, string(k))
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	sort.Strings(keys)
+	keys = compactStrings(keys)
+
+	// Fetch the values and remaining lifetimes of all keys in one round trip.
+	cmds := make([][]string, 0, 2*len(keys))
+	for _, k := range keys {
+		cmds = append(cmds, []string{"GET", k}, []string{"PTTL", k})
+	}
+	replies, err := r.do(ctx, cmds...)
+	if err != nil {
+		return nil, fmt.Errorf("store: failed to list %q: %w", prefix, err)
+	}
+	now := time.Now()
+	out := make([]Entry, 0, len(keys))
+	for i, k := range keys {
+		value, ok := replies[2*i].([]byte)
+		if !ok {
+			continue // Expired or deleted since the scan.
+		}
+		e := Entry{Key: k, Value: value}
+		if ttl, ok := replies[2*i+1].(int64); ok && ttl > 0 {
+			e.ExpiresAt = now.Add(time.Duration(ttl) * time.Millisecond)
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// Close closes the idle connections to the server.
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	idle := r.idle
+	r.idle = nil
+	r.mu.Unlock()
+	var errs []error
+	for _, c := range idle {
+		errs = append(errs, c.conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// redisGlobEscaper escapes the characters that are special in SCAN MATCH patterns.
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// compactStrings removes adjacent duplicates from sorted ss; SCAN may return a key more than once.
+func compactStrings(ss []string) []string {
+	out := ss[:0]
+	for i, s := range ss {
+		if i == 0 || s != ss[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisConn is a connection to the server.
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+	wr   *bufio.Writer
+}
+
+// do sends cmds to the server in one round trip and returns their replies, in order. A reply is
+// a []byte, an int64, a string (status), nil, or a []any of replies. Error replies are returned
+// as errors.
+func (r *Redis) do(ctx context.Context, cmds ...[]string) ([]any, error) {
+	c, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := c.roundTrip(ctx, cmds)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state after an I/O or protocol error.
+		c.conn.Close()
+		return nil, err
+	}
+	r.release(c)
+	return replies, err
+}
+
+// conn returns an idle connection, or opens a new one.
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	r.mu.Lock()
+	if n := len(r.idle); n > 0 {
+		c := r.idle[n-1]
+		r.idle = r.idle[:n-1]
+		r.mu.Unlock()
+		return c, nil
+	}
+	r.mu.Unlock()
+
+	nc, err := r.dialer.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", r.addr, err)
+	}
+	c := &redisConn{conn: nc, rd: bufio.NewReader(nc), wr: bufio.NewWriter(nc)}
+	var setup [][]string
+	if r.opts.Password != "" {
+		if r.opts.Username != "" {
+			setup = append(setup, []string{"AUTH", r.opts.Username, r.opts.Password})
+		} else {
+			setup = append(setup, []string{"AUTH", r.opts.Password})
+		}
+	}
+	if r.opts.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.opts.DB)})
+	}
+	if len(setup) > 0 {
+		if _, err := c.roundTrip(ctx, setup); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("failed to set up connection to %s: %w", r.addr, err)
+		}
+	}
+	return c, nil
+}
+
+// release returns c to the idle connections, or closes it if there are enough of them.
+func (r *Redis) release(c *redisConn) {
+	r.mu.Lock()
+	if len(r.idle) < redisMaxIdleConns {
+		r.idle = append(r.idle, c)
+		c = nil
+	}
+	r.mu.Unlock()
+	if c != nil {
+		c.conn.Close()
+	}
+}
+
+// roundTrip writes cmds and reads one reply for each of them. If a command fails, the first
+// error reply is returned after all replies have been read, keeping the connection usable.
+func (c *redisConn) roundTrip(ctx context.Context, cmds [][]string) (_ []any, err error) {
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	// Unblock reads and writes when ctx is canceled. If that happened, the connection's
+	// deadline has passed, so it is reported as unusable even if the commands completed.
+	stop := context.AfterFunc(ctx, func() { c.conn.SetDeadline(time.Unix(1, 0)) })
+	defer func() {
+		if !stop() {
+			err = ctx.Err()
+		}
+	}()
+
+	for _, cmd := range cmds {
+		fmt.Fprintf(c.wr, "*%d\r\n", len(cmd))
+		for _, arg := range cmd {
+			fmt.Fprintf(c.wr, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if err := c.wr.Flush(); err != nil {
+		return nil, errors.Join(ctx.Err(), err)
+	}
+
+	replies := make([]any, len(cmds))
+	var replyErr error
+	for i := range cmds {
+		reply, err := readRedisReply(c.rd)
+		var e redisError
+		switch {
+		case errors.As(err, &e):
+			if replyErr == nil {
+				replyErr = e
+			}
+		case err != nil:
+			return nil, errors.Join(ctx.Err(), err)
+		}
+		replies[i] = reply
+	}
+	return replies, replyErr
+}
+
+// readRedisReply reads one complete reply from rd. An error reply, including one nested in an
+// array, is returned as a redisError once the whole reply has been read, so the connection stays
+// usable.
+func readRedisReply(rd *bufio.Reader) (any, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		// Read every element even if one is an error reply, so that the rest of the array is
+		// not left unread on the connection.
+		items := make([]any, n)
+		var replyErr error
+		for i := range items {
+			item, err := readRedisReply(rd)
+			var e redisError
+			switch {
+			case errors.As(err, &e):
+				if replyErr == nil {
+					replyErr = e
+				}
+			case err != nil:
+				return nil, err
+			}
+			items[i] = item
+		}
+		if replyErr != nil {
+			return nil, replyErr
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
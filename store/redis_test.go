@@ -0,0 +1,222 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadRedisReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{name: "status", input: "+OK\r\n", want: "OK"},
+		{name: "integer", input: ":42\r\n", want: "42"},
+		{name: "bulk", input: "$5\r\nhe\r\no\r\n", want: "[104 101 13 10 111]"},
+		{name: "nil bulk", input: "$-1\r\n", want: "<nil>"},
+		{name: "array", input: "*2\r\n$1\r\na\r\n:1\r\n", want: "[[97] 1]"},
+		{name: "error", input: "-ERR boom\r\n", wantErr: "redis: ERR boom"},
+		{name: "error in array", input: "*3\r\n:1\r\n-ERR boom\r\n$1\r\nb\r\n", wantErr: "redis: ERR boom"},
+		{name: "unexpected", input: "?\r\n", wantErr: `redis: unexpected reply "?"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A trailing reply checks that exactly one reply was consumed.
+			rd := bufio.NewReader(strings.NewReader(tt.input + "+NEXT\r\n"))
+			got, err := readRedisReply(rd)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("readRedisReply() error = %v, want %q", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("readRedisReply() error = %v", err)
+				}
+				if s := fmt.Sprint(got); s != tt.want {
+					t.Errorf("readRedisReply() = %s, want %s", s, tt.want)
+				}
+			}
+			if tt.name == "unexpected" {
+				return
+			}
+			next, err := readRedisReply(rd)
+			if err != nil || next != "NEXT" {
+				t.Errorf("next reply = %v, %v; want NEXT", next, err)
+			}
+		})
+	}
+}
+
+// fakeRedis serves a minimal in-memory subset of the Redis protocol. The FAIL command replies
+// with an array holding an error element.
+type fakeRedis struct {
+	mu      sync.Mutex
+	entries map[string]string
+	ttls    map[string]int64
+}
+
+func startFakeRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	f := &fakeRedis{entries: make(map[string]string), ttls: make(map[string]int64)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		req, err := readRedisReply(rd)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, a := range req.([]any) {
+			args = append(args, string(a.([]byte)))
+		}
+		fmt.Fprint(conn, f.exec(args))
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bulk := func(s string) string { return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s) }
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		f.entries[args[1]] = args[2]
+		delete(f.ttls, args[1])
+		if len(args) == 5 {
+			var ms int64
+			fmt.Sscan(args[4], &ms)
+			f.ttls[args[1]] = ms
+		}
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.entries[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "DEL":
+		delete(f.entries, args[1])
+		return ":1\r\n"
+	case "PTTL":
+		if ms, ok := f.ttls[args[1]]; ok {
+			return fmt.Sprintf(":%d\r\n", ms)
+		}
+		return ":-1\r\n"
+	case "SCAN":
+		prefix := strings.TrimSuffix(args[3], "*")
+		var keys []string
+		for k := range f.entries {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		out := fmt.Sprintf("*2\r\n%s*%d\r\n", bulk("0"), len(keys))
+		for _, k := range keys {
+			out += bulk(k)
+		}
+		return out
+	case "FAIL":
+		return "*2\r\n-ERR boom\r\n" + bulk("left over")
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	r, err := NewRedis(startFakeRedis(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if err := r.Set(ctx, "a:1", []byte("one"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(ctx, "a:2", []byte("two"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(ctx, "b:1", []byte("other"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok, err := r.Get(ctx, "a:1")
+	if err != nil || !ok || string(got) != "one" {
+		t.Fatalf("Get(a:1) = %q, %v, %v", got, ok, err)
+	}
+	if _, ok, err := r.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("Get(missing) = %v, %v", ok, err)
+	}
+
+	entries, err := r.List(ctx, "a:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Key != "a:1" || entries[1].Key != "a:2" {
+		t.Fatalf("List(a:) = %v", entries)
+	}
+	if entries[0].ExpiresAt.IsZero() || !entries[1].ExpiresAt.IsZero() {
+		t.Errorf("List(a:) expiries = %v, %v", entries[0].ExpiresAt, entries[1].ExpiresAt)
+	}
+
+	if err := r.Delete(ctx, "a:1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := r.Get(ctx, "a:1"); ok {
+		t.Error("Get(a:1) found a deleted entry")
+	}
+}
+
+func TestRedisErrorInArrayKeepsConnectionUsable(t *testing.T) {
+	ctx := context.Background()
+	r, err := NewRedis(startFakeRedis(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.Set(ctx, "k", []byte("v"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.do(ctx, []string{"FAIL"})
+	var replyErr redisError
+	if !errors.As(err, &replyErr) {
+		t.Fatalf("do(FAIL) error = %v, want a reply error", err)
+	}
+	if len(r.idle) != 1 {
+		t.Fatalf("idle connections = %d, want the connection back in the pool", len(r.idle))
+	}
+
+	got, ok, err := r.Get(ctx, "k")
+	if err != nil || !ok || string(got) != "v" {
+		t.Fatalf("Get(k) after an error reply = %q, %v, %v", got, ok, err)
+	}
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// tableNamePattern matches the table names accepted by NewSQLite.
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLite is a Store persisted in a table of a SQLite database. The database is opened by the
+// application with the driver of its choice (e.g., modernc.org/sqlite or
+// github.com/mattn/go-sqlite3), so this package does not depend on one. Unlike File, it suits
+// high-throughput caches and can be shared by several processes.
+type SQLite struct {
+	db    *sql.DB
+	table string
+	now   func() time.Time
+}
+
+// NewSQLite creates a Store persisted in table of db, creating the table if it does not exist.
+// table must be a plain SQL identifier.
+func NewSQLite(ctx context.Context, db *sql.DB, table string) (*SQLite, error) {
+	if db == nil {
+		return nil, errors.New("store: database cannot be nil")
+	}
+	if !tableNamePattern.MatchString(table) {
+		return nil, fmt.Errorf("store: invalid table name %q", table)
+	}
+	// expires_at is in Unix nanoseconds; 0 means the entry never expires.
+	stmt := "CREATE TABLE IF NOT EXISTS " + table + ` (
+		key TEXT PRIMARY KEY,
+		value BLOB NOT NULL,
+		expires_at INTEGER NOT NULL DEFAULT 0
+	)`
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return nil, fmt.Errorf("store: failed to create table %s: %w", table, err)
+	}
+	return &SQLite{db: db, table: table, now: time.Now}, nil
+}
+
+// Get implements Store.
+func (s *SQLite) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if key == "" {
+		return nil, false, ErrInvalidKey
+	}
+	var value []byte
+	err := s.db.QueryRowContext(ctx,
+		"SELECT value FROM "+s.table+" WHERE key = ? AND (expires_at = 0 OR expires_at > ?)",
+		key, s.now().UnixNano()).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("store: failed to get %q: %w", key, err)
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	return value, true, nil
+}
+
+// Set implements Store.
+func (s *SQLite) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = s.now().Add(ttl).UnixNano()
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	_, err := s.db.ExecContext(ctx,
+		"INSERT OR REPLACE INTO "+s.table+" (key, value, expires_at) VALUES (?, ?, ?)",
+		key, value, expiresAt)
+	if err != nil {
+		return fmt.Errorf("store: failed to set %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *SQLite) Delete(ctx context.Context, key string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM "+s.table+" WHERE key = ?", key); err != nil {
+		return fmt.Errorf("store: failed to delete %q: %w", key, err)
+	}
+	return nil
+}
+
+// List implements Store.
+func (s *SQLite) List(ctx context.Context, prefix string) ([]Entry, error) {
+	// Keys are compared bytewise, so the keys with prefix are the ones from prefix up to the
+	// first key without it.
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT key, value, expires_at FROM "+s.table+" WHERE key >= ? AND (expires_at = 0 OR expires_at > ?) ORDER BY key",
+		prefix, s.now().UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("store: failed to list %q: %w", prefix, err)
+	}
+	defer rows.Close()
+
+	var out []Entry
+	for rows.Next() {
+		var e Entry
+		var expiresAt int64
+		if err := rows.Scan(&e.Key, &e.Value, &expiresAt); err != nil {
+			return nil, fmt.Errorf("store: failed to list %q: %w", prefix, err)
+		}
+		if !strings.HasPrefix(e.Key, prefix) {
+			break
+		}
+		if e.Value == nil {
+			e.Value = []byte{}
+		}
+		if expiresAt != 0 {
+			e.ExpiresAt = time.Unix(0, expiresAt)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("store: failed to list %q: %w", prefix, err)
+	}
+	return out, nil
+}
+
+// Purge deletes expired entries and returns how many were deleted.
+// Expired entries are never returned by other methods, so calling Purge is only
+// needed to reclaim space.
+func (s *SQLite) Purge(ctx context.Context) (int, error) {
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM "+s.table+" WHERE expires_at != 0 AND expires_at <= ?", s.now().UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("store: failed to purge expired entries: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("store: failed to purge expired entries: %w", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,151 @@
+/*
+Package store defines the storage interface shared by the library's persistent features,
+so deployments configure persistence once instead of per feature. The URL resolution cache
+(see search.WithURLResolutionCache) and the history of the gemini-search CLI are built on it.
+
+The following implementations are provided, and other backends can be plugged in by
+implementing Store:
+
+  - Memory keeps entries in process.
+  - File persists entries as a JSON file, for small data such as histories.
+  - SQLite persists entries in a table of a SQLite database opened by the application.
+  - Redis persists entries in a Redis server, for sharing them between processes and hosts.
+
+For example, to share the URL resolution cache of several processes through Redis:
+
+	s, err := store.NewRedis("localhost:6379", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := search.NewClient(ctx, apiKey,
+		search.WithNoRedirection(),
+		search.WithURLResolutionCache(s, 0))
+*/
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidKey is returned when an empty key is used.
+var ErrInvalidKey = errors.New("store: key cannot be empty")
+
+// Entry is a stored key/value pair.
+type Entry struct {
+	// Key is the entry's key.
+	Key string
+
+	// Value is the stored value.
+	Value []byte
+
+	// ExpiresAt is when the entry expires. The zero value means it never expires.
+	ExpiresAt time.Time
+}
+
+// Store is a key/value store with prefix queries.
+// Implementations must be safe for concurrent use. Expired entries must not be returned.
+type Store interface {
+	// Get returns the value stored under key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key. If ttl is positive, the entry expires after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns all entries whose key starts with prefix, ordered by key.
+	List(ctx context.Context, prefix string) ([]Entry, error)
+}
+
+// Memory is an in-process Store backed by a map.
+type Memory struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+	now     func() time.Time
+}
+
+// NewMemory creates an empty in-memory Store.
+func NewMemory() *Memory {
+	return &Memory{
+		entries: make(map[string]Entry),
+		now:     time.Now,
+	}
+}
+
+// Get implements Store.
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	if key == "" {
+		return nil, false, ErrInvalidKey
+	}
+	m.mu.RLock()
+	e, ok := m.entries[key]
+	m.mu.RUnlock()
+	if !ok || m.expired(e) {
+		return nil, false, nil
+	}
+	return append([]byte(nil), e.Value...), true, nil
+}
+
+// Set implements Store.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	e := Entry{Key: key, Value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.ExpiresAt = m.now().Add(ttl)
+	}
+	m.mu.Lock()
+	m.entries[key] = e
+	m.mu.Unlock()
+	return nil
+}
+
+// Delete implements Store.
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.entries, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// List implements Store.
+func (m *Memory) List(_ context.Context, prefix string) ([]Entry, error) {
+	m.mu.RLock()
+	var out []Entry
+	for k, e := range m.entries {
+		if strings.HasPrefix(k, prefix) && !m.expired(e) {
+			e.Value = append([]byte(nil), e.Value...)
+			out = append(out, e)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// Purge removes expired entries and returns how many were removed.
+// Expired entries are never returned by other methods, so calling Purge is only
+// needed to reclaim memory.
+func (m *Memory) Purge() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for k, e := range m.entries {
+		if m.expired(e) {
+			delete(m.entries, k)
+			n++
+		}
+	}
+	return n
+}
+
+func (m *Memory) expired(e Entry) bool {
+	return !e.ExpiresAt.IsZero() && !m.now().Before(e.ExpiresAt)
+}
@@ -0,0 +1,384 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+	"google.golang.org/genai"
+)
+
+// StreamChunk is an increment of a response streamed by GenerateGroundedContentStreamFunc.
+type StreamChunk struct {
+	// Text is the answer text received since the previous chunk.
+	Text string
+
+	// Thought is the thought summary text received since the previous chunk. It is only sent
+	// when thoughts are requested (see ThinkingConfig.IncludeThoughts).
+	Thought string
+
+	// Attributions lists the sources the grounding metadata of the chunk cites that no earlier
+	// chunk cited, e.g., to render source chips while the answer is streamed. Their URLs are as
+	// returned by the API, without URL resolution, and the sources are not yet filtered by the
+	// client's domain, confidence, or site settings; the GroundingAttributions of the returned
+	// response are the final sources.
+	Attributions []GroundingAttribution
+
+	// Usage is the token usage reported so far, or nil if the chunk reported none.
+	Usage *StreamUsage
+
+	// Restart reports that Text starts over: the text, thoughts, and attributions sent before
+	// this chunk were superseded, e.g., by a retry or by the final answer differing from the
+	// streamed one, and should be discarded.
+	Restart bool
+}
+
+// StreamUsage is the token usage of a streamed response so far.
+type StreamUsage struct {
+	// PromptTokens is the number of tokens in the prompt.
+	PromptTokens int32
+
+	// OutputTokens is the number of tokens generated for the answer.
+	OutputTokens int32
+
+	// ThoughtsTokens is the number of tokens the model spent thinking.
+	ThoughtsTokens int32
+
+	// TotalTokens is the total number of tokens.
+	TotalTokens int32
+}
+
+// StreamFunc receives the chunks of a streamed response. Returning an error cancels the stream.
+type StreamFunc func(chunk StreamChunk) error
+
+// streamKey is the context key of the streamer of a GenerateGroundedContentStreamFunc call.
+type streamKey struct{}
+
+// streamer forwards the chunks of the generation requests of a call to its StreamFunc.
+type streamer struct {
+	fn StreamFunc
+
+	// text is the answer text sent since the last restart.
+	text strings.Builder
+
+	// cited holds the URLs of the attributions sent since the last restart.
+	cited map[string]bool
+
+	// sent reports whether any text, thought, or attribution was sent; restart whether the
+	// next chunk starts a new generation request.
+	sent, restart bool
+}
+
+// streamAbortedError is returned by generateContent when the StreamFunc returned err.
+type streamAbortedError struct {
+	err error
+}
+
+func (e *streamAbortedError) Error() string { return "stream aborted: " + e.err.Error() }
+
+func (e *streamAbortedError) Unwrap() error { return e.err }
+
+// streamerFrom returns the streamer of ctx, or nil if the call is not streamed.
+func streamerFrom(ctx context.Context) *streamer {
+	s, _ := ctx.Value(streamKey{}).(*streamer)
+	return s
+}
+
+// begin marks the start of a generation request, whose text supersedes any sent before.
+func (s *streamer) begin() {
+	s.restart = s.sent
+	s.cited = nil
+}
+
+// send forwards the text, thoughts, new attributions, and usage of the first candidate in chunk.
+func (s *streamer) send(chunk *genai.GenerateContentResponse) error {
+	var out StreamChunk
+	for _, cand := range chunk.Candidates {
+		if cand == nil || cand.Index != 0 {
+			continue
+		}
+		out.Attributions = append(out.Attributions, s.newAttributions(cand.GroundingMetadata)...)
+		if cand.Content == nil {
+			continue
+		}
+		for _, part := range cand.Content.Parts {
+			if part == nil || !isPlainText(part) {
+				continue
+			}
+			if part.Thought {
+				out.Thought += part.Text
+			} else {
+				out.Text += part.Text
+			}
+		}
+	}
+	if u := chunk.UsageMetadata; u != nil {
+		out.Usage = &StreamUsage{
+			PromptTokens:   u.PromptTokenCount,
+			OutputTokens:   u.CandidatesTokenCount,
+			ThoughtsTokens: u.ThoughtsTokenCount,
+			TotalTokens:    u.TotalTokenCount,
+		}
+	}
+	if out.Text == "" && out.Thought == "" && len(out.Attributions) == 0 && out.Usage == nil {
+		return nil
+	}
+	if s.restart {
+		out.Restart = true
+		s.restart = false
+		s.text.Reset()
+	}
+	if out.Text != "" || out.Thought != "" || len(out.Attributions) > 0 {
+		s.sent = true
+	}
+	s.text.WriteString(out.Text)
+	return s.fn(out)
+}
+
+// newAttributions returns the attributions of metadata whose URLs were not sent yet. Streamed
+// grounding metadata may repeat sources of earlier chunks.
+func (s *streamer) newAttributions(metadata *genai.GroundingMetadata) []GroundingAttribution {
+	attrs, err := extractGroundingMetadata(metadata)
+	if err != nil {
+		// The sources are still returned with the complete response.
+		return nil
+	}
+	var out []GroundingAttribution
+	for _, attr := range attrs {
+		if attr.URL == "" || s.cited[attr.URL] {
+			continue
+		}
+		if s.cited == nil {
+			s.cited = make(map[string]bool)
+		}
+		s.cited[attr.URL] = true
+		out = append(out, attr)
+	}
+	assignAttributionIDs(out)
+	return out
+}
+
+// finish sends the final answer text if it differs from the streamed text, e.g., because the
+// response did not come from the API or a retry was discarded.
+func (s *streamer) finish(resp *Response) error {
+	if resp == nil || resp.GeneratedText == s.text.String() {
+		return nil
+	}
+	chunk := StreamChunk{Text: resp.GeneratedText, Restart: s.sent}
+	if !s.sent {
+		chunk.Thought = resp.ThoughtSummary
+	}
+	s.sent = true
+	s.text.Reset()
+	s.text.WriteString(resp.GeneratedText)
+	return s.fn(chunk)
+}
+
+// GenerateGroundedContentStreamFunc works like GenerateGroundedContentWithParams, but streams the
+// response: fn is called with the text, thought, attribution, and usage increments of the first
+// candidate as they arrive, and the complete response, including its final grounding
+// attributions, is returned when generation is done. fn is called from the calling goroutine,
+// one chunk at a time.
+//
+// If fn returns an error, the stream is canceled and the error is returned. Internal re-queries
+// are streamed as well, starting with a chunk with Restart set. If the returned answer differs
+// from the streamed text, e.g., because an interceptor answered the request, it is sent as a
+// final chunk.
+func (c *Client) GenerateGroundedContentStreamFunc(ctx context.Context, params *GenerationParams, fn StreamFunc) (*Response, error) {
+	if fn == nil {
+		return nil, ierrors.Wrap(ErrInvalidParameter, "stream function cannot be nil")
+	}
+	ctx, cancel, err := c.bindToClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	s := &streamer{fn: fn}
+	resp, err := c.generateWithHooks(context.WithValue(ctx, streamKey{}, s), params)
+	if err != nil {
+		return resp, err
+	}
+	if err := s.finish(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// generateContent makes a single GenerateContent call. The response is streamed instead if the
+// call is streamed (see GenerateGroundedContentStreamFunc) or a soft timeout is configured, and
+// if softDeadline passes before the stream completes, the text and metadata received so far are
+// returned with partial set to true. A zero softDeadline disables the soft timeout.
+func (c *Client) generateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig, softDeadline time.Time) (resp *genai.GenerateContentResponse, partial bool, err error) {
+	genaiClient, record := c.generationClient()
+	defer func() { record(err) }()
+
+	s := streamerFrom(ctx)
+	if softDeadline.IsZero() && s == nil {
+		resp, err := genaiClient.Models.GenerateContent(ctx, model, contents, config)
+		return resp, false, err
+	}
+
+	var streamCtx context.Context
+	var cancel context.CancelFunc
+	if softDeadline.IsZero() {
+		streamCtx, cancel = context.WithCancel(ctx)
+	} else {
+		streamCtx, cancel = context.WithDeadline(ctx, softDeadline)
+	}
+	defer cancel()
+
+	if s != nil {
+		s.begin()
+	}
+	var acc streamAccumulator
+	for chunk, err := range genaiClient.Models.GenerateContentStream(streamCtx, model, contents, config) {
+		if err != nil {
+			// Salvage what has arrived only if the soft deadline, not the caller's context, ended the stream.
+			if ctx.Err() == nil && streamCtx.Err() != nil && acc.hasText() {
+				return acc.response(), true, nil
+			}
+			return nil, false, err
+		}
+		acc.add(chunk)
+		if s != nil && chunk != nil {
+			if err := s.send(chunk); err != nil {
+				return nil, false, &streamAbortedError{err: err}
+			}
+		}
+	}
+	return acc.response(), false, nil
+}
+
+// streamAccumulator merges the chunks of a streamed response into a single response.
+type streamAccumulator struct {
+	resp       *genai.GenerateContentResponse
+	candidates map[int32]*genai.Candidate
+	order      []int32
+}
+
+// add merges chunk into the accumulated response. Text parts are concatenated per candidate;
+// other parts are kept as they arrive. Metadata fields take the latest non-empty value.
+func (a *streamAccumulator) add(chunk *genai.GenerateContentResponse) {
+	if chunk == nil {
+		return
+	}
+	if a.resp == nil {
+		a.resp = &genai.GenerateContentResponse{}
+		a.candidates = make(map[int32]*genai.Candidate)
+	}
+	if chunk.PromptFeedback != nil {
+		a.resp.PromptFeedback = chunk.PromptFeedback
+	}
+	if chunk.UsageMetadata != nil {
+		a.resp.UsageMetadata = chunk.UsageMetadata
+	}
+	if chunk.ModelVersion != "" {
+		a.resp.ModelVersion = chunk.ModelVersion
+	}
+	if chunk.ResponseID != "" {
+		a.resp.ResponseID = chunk.ResponseID
+	}
+	if !chunk.CreateTime.IsZero() {
+		a.resp.CreateTime = chunk.CreateTime
+	}
+	a.resp.SDKHTTPResponse = chunk.SDKHTTPResponse
+
+	for _, cand := range chunk.Candidates {
+		if cand == nil {
+			continue
+		}
+		merged, ok := a.candidates[cand.Index]
+		if !ok {
+			merged = &genai.Candidate{Index: cand.Index}
+			a.candidates[cand.Index] = merged
+			a.order = append(a.order, cand.Index)
+		}
+		mergeCandidate(merged, cand)
+	}
+}
+
+// mergeCandidate merges a streamed candidate chunk into dst.
+func mergeCandidate(dst, src *genai.Candidate) {
+	if src.Content != nil {
+		if dst.Content == nil {
+			dst.Content = &genai.Content{Role: src.Content.Role}
+		}
+		for _, part := range src.Content.Parts {
+			if part == nil {
+				continue
+			}
+			if n := len(dst.Content.Parts); n > 0 && isPlainText(part) && isPlainText(dst.Content.Parts[n-1]) && dst.Content.Parts[n-1].Thought == part.Thought {
+				last := *dst.Content.Parts[n-1]
+				last.Text += part.Text
+				dst.Content.Parts[n-1] = &last
+				continue
+			}
+			dst.Content.Parts = append(dst.Content.Parts, part)
+		}
+	}
+	if src.FinishReason != "" {
+		dst.FinishReason = src.FinishReason
+		dst.FinishMessage = src.FinishMessage
+	}
+	if src.GroundingMetadata != nil {
+		dst.GroundingMetadata = src.GroundingMetadata
+	}
+	if src.URLContextMetadata != nil {
+		dst.URLContextMetadata = src.URLContextMetadata
+	}
+	if src.CitationMetadata != nil {
+		dst.CitationMetadata = src.CitationMetadata
+	}
+	if len(src.SafetyRatings) > 0 {
+		dst.SafetyRatings = src.SafetyRatings
+	}
+	if src.LogprobsResult != nil {
+		if dst.LogprobsResult == nil {
+			dst.LogprobsResult = &genai.LogprobsResult{}
+		}
+		dst.LogprobsResult.ChosenCandidates = append(dst.LogprobsResult.ChosenCandidates, src.LogprobsResult.ChosenCandidates...)
+		dst.LogprobsResult.TopCandidates = append(dst.LogprobsResult.TopCandidates, src.LogprobsResult.TopCandidates...)
+	}
+	if src.AvgLogprobs != 0 {
+		dst.AvgLogprobs = src.AvgLogprobs
+	}
+	if src.TokenCount != 0 {
+		dst.TokenCount = src.TokenCount
+	}
+}
+
+// isPlainText reports whether part carries only text.
+func isPlainText(p *genai.Part) bool {
+	return p.Text != "" && p.FunctionCall == nil && p.FunctionResponse == nil &&
+		p.InlineData == nil && p.FileData == nil && p.ExecutableCode == nil && p.CodeExecutionResult == nil
+}
+
+// hasText reports whether any answer text, as opposed to thoughts, has been received.
+func (a *streamAccumulator) hasText() bool {
+	for _, cand := range a.candidates {
+		if cand.Content == nil {
+			continue
+		}
+		for _, part := range cand.Content.Parts {
+			if part.Text != "" && !part.Thought {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// response returns the accumulated response, with candidates in the order they first arrived.
+// It returns nil if no chunk was received.
+func (a *streamAccumulator) response() *genai.GenerateContentResponse {
+	if a.resp == nil {
+		return nil
+	}
+	a.resp.Candidates = make([]*genai.Candidate, 0, len(a.order))
+	for _, idx := range a.order {
+		a.resp.Candidates = append(a.resp.Candidates, a.candidates[idx])
+	}
+	return a.resp
+}
@@ -0,0 +1,166 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// SummarizeOptions configures Client.GroundedSummarize.
+type SummarizeOptions struct {
+	// ChunkSize is the maximum length of each chunk of the input, in characters.
+	// Chunks are split on paragraph, line, or sentence boundaries where possible.
+	// If zero, DefaultSummarizeChunkSize is used.
+	ChunkSize int
+
+	// ModelName overrides the client's default model for every step of the pipeline.
+	ModelName string
+
+	// Instructions is optional extra guidance for the final summary
+	// (e.g., "focus on financial figures", "answer in Japanese").
+	Instructions string
+}
+
+// SummaryResult is the output of Client.GroundedSummarize.
+type SummaryResult struct {
+	// Summary is the final, grounded summary of the input.
+	Summary string `json:"summary"`
+
+	// GroundingAttributions lists the web sources cited by the summary's claims.
+	GroundingAttributions []GroundingAttribution `json:"grounding_attributions,omitempty"`
+
+	// ChunkSummaries holds the intermediate summary of each chunk, in input order.
+	ChunkSummaries []string `json:"chunk_summaries,omitempty"`
+
+	// Response is the grounded response that produced Summary.
+	Response *Response `json:"-"`
+}
+
+// GroundedSummarize summarizes a long document and grounds the summary in web sources.
+// The text is split into chunks which are summarized one by one, without searching, so the
+// chunk summaries only reflect the text. The chunk summaries are then merged in a final
+// grounded request that verifies, corrects, and extends the key claims with Google Search,
+// so the returned summary carries web citations.
+// opts may be nil to use the defaults.
+func (c *Client) GroundedSummarize(ctx context.Context, longText string, opts *SummarizeOptions) (*SummaryResult, error) {
+	if strings.TrimSpace(longText) == "" {
+		return nil, ierrors.Wrap(ErrInvalidParameter, "text to summarize cannot be empty")
+	}
+	if opts == nil {
+		opts = &SummarizeOptions{}
+	}
+	if opts.ChunkSize < 0 {
+		return nil, ierrors.Wrapf(ErrInvalidParameter, "chunk size cannot be negative, got %d", opts.ChunkSize)
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultSummarizeChunkSize
+	}
+
+	chunks := splitText(longText, chunkSize)
+	summaries := make([]string, 0, len(chunks))
+	if len(chunks) > 1 {
+		for i, chunk := range chunks {
+			resp, err := c.GenerateGroundedContentWithParams(ctx, &GenerationParams{
+				Prompt:        buildChunkSummaryPrompt(chunk, i+1, len(chunks)),
+				ModelName:     opts.ModelName,
+				disableSearch: true,
+			})
+			if err != nil {
+				return nil, ierrors.Wrapf(err, "failed to summarize chunk %d of %d", i+1, len(chunks))
+			}
+			summaries = append(summaries, resp.GeneratedText)
+		}
+	} else {
+		summaries = append(summaries, chunks[0])
+	}
+
+	resp, err := c.GenerateGroundedContentWithParams(ctx, &GenerationParams{
+		Prompt:    buildGroundedSummaryPrompt(summaries, len(chunks) > 1, opts.Instructions),
+		ModelName: opts.ModelName,
+	})
+	if err != nil {
+		return nil, ierrors.Wrap(err, "failed to generate grounded summary")
+	}
+
+	result := &SummaryResult{
+		Summary:               resp.GeneratedText,
+		GroundingAttributions: resp.GroundingAttributions,
+		Response:              resp,
+	}
+	if len(chunks) > 1 {
+		result.ChunkSummaries = summaries
+	}
+	return result, nil
+}
+
+// buildChunkSummaryPrompt builds the prompt used to summarize a single chunk.
+func buildChunkSummaryPrompt(chunk string, index, total int) string {
+	return fmt.Sprintf(`Summarize part %d of %d of a longer document.
+Keep every key claim, figure, date, and named entity. Do not add information that is not in the text.
+
+<document_part>
+%s
+</document_part>`, index, total, chunk)
+}
+
+// buildGroundedSummaryPrompt builds the prompt for the final grounded summary.
+// When fromSummaries is false, material is the original text rather than chunk summaries.
+func buildGroundedSummaryPrompt(material []string, fromSummaries bool, instructions string) string {
+	var b strings.Builder
+	if fromSummaries {
+		b.WriteString("The following are summaries of consecutive parts of a long document.\n")
+		b.WriteString("Merge them into a single coherent summary of the whole document.\n")
+	} else {
+		b.WriteString("Summarize the following document.\n")
+	}
+	b.WriteString("Use Google Search to verify the key factual claims. Correct claims that are outdated or wrong, ")
+	b.WriteString("add relevant up-to-date context, and clearly note any claim that could not be verified.\n")
+	if instructions != "" {
+		b.WriteString("\nAdditional instructions:\n")
+		b.WriteString(instructions)
+		b.WriteString("\n")
+	}
+	for i, m := range material {
+		if fromSummaries {
+			fmt.Fprintf(&b, "\n<part_summary index=\"%d\">\n%s\n</part_summary>\n", i+1, m)
+		} else {
+			fmt.Fprintf(&b, "\n<document>\n%s\n</document>\n", m)
+		}
+	}
+	return b.String()
+}
+
+// splitText splits text into chunks of at most size runes, preferring to break at
+// paragraph boundaries, then line breaks, then sentence ends, then spaces.
+func splitText(text string, size int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var chunks []string
+	for len(runes) > size {
+		cut := findSplitPoint(runes[:size])
+		chunk := strings.TrimSpace(string(runes[:cut]))
+		if chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
+
+// findSplitPoint returns the index just after the best boundary within window.
+// It falls back to len(window) if no boundary is found in the second half of the window.
+func findSplitPoint(window []rune) int {
+	s := string(window)
+	minCut := len(s) / 2
+	for _, sep := range []string{"\n\n", "\n", ". ", "。", " "} {
+		if i := strings.LastIndex(s, sep); i >= minCut {
+			return len([]rune(s[:i+len(sep)]))
+		}
+	}
+	return len(window)
+}
@@ -1,9 +1,36 @@
 package search
 
 import (
+	"time"
+
 	"google.golang.org/genai"
 )
 
+// --- Backends ---
+
+// Backend selects the Google API service the client talks to.
+type Backend string
+
+// Constants for Backend
+const (
+	// BackendGeminiAPI is the Gemini Developer API (generativelanguage.googleapis.com).
+	BackendGeminiAPI Backend = "gemini-api"
+	// BackendVertexAI is Vertex AI (aiplatform.googleapis.com).
+	BackendVertexAI Backend = "vertex-ai"
+)
+
+// toSDK converts the Backend to the SDK's genai.Backend.
+func (b Backend) toSDK() genai.Backend {
+	switch b {
+	case BackendVertexAI:
+		return genai.BackendVertexAI
+	case BackendGeminiAPI:
+		return genai.BackendGeminiAPI
+	default:
+		return genai.BackendUnspecified
+	}
+}
+
 // --- Harm Categories and Block Thresholds ---
 
 // HarmCategory defines the type of harmful content for your application.
@@ -43,6 +70,134 @@ type SafetySetting struct {
 	Threshold HarmBlockThreshold `json:"threshold"`
 }
 
+// HarmProbability is the likelihood that content belongs to a harm category,
+// as reported in safety ratings.
+type HarmProbability string
+
+// Constants for HarmProbability
+const (
+	HarmProbabilityUnspecified HarmProbability = "HARM_PROBABILITY_UNSPECIFIED"
+	HarmProbabilityNegligible  HarmProbability = "NEGLIGIBLE"
+	HarmProbabilityLow         HarmProbability = "LOW"
+	HarmProbabilityMedium      HarmProbability = "MEDIUM"
+	HarmProbabilityHigh        HarmProbability = "HIGH"
+)
+
+// SafetyRating is the safety assessment of a piece of content for a single harm category.
+type SafetyRating struct {
+	// Category is the harm category this rating applies to.
+	Category HarmCategory `json:"category"`
+
+	// Probability is the likelihood that the content belongs to the category.
+	Probability HarmProbability `json:"probability,omitempty"`
+
+	// Blocked reports whether the content was filtered because of this rating.
+	Blocked bool `json:"blocked,omitempty"`
+}
+
+// newSafetyRatings converts SDK safety ratings into library-owned SafetyRating values.
+func newSafetyRatings(ratings []*genai.SafetyRating) []SafetyRating {
+	if len(ratings) == 0 {
+		return nil
+	}
+	out := make([]SafetyRating, 0, len(ratings))
+	for _, r := range ratings {
+		if r == nil {
+			continue
+		}
+		out = append(out, SafetyRating{
+			Category:    HarmCategory(r.Category),
+			Probability: HarmProbability(r.Probability),
+			Blocked:     r.Blocked,
+		})
+	}
+	return out
+}
+
+// BlockReason describes why a prompt was blocked by the API.
+type BlockReason string
+
+// Constants for BlockReason
+const (
+	BlockReasonUnspecified       BlockReason = "BLOCKED_REASON_UNSPECIFIED"
+	BlockReasonSafety            BlockReason = "SAFETY"
+	BlockReasonOther             BlockReason = "OTHER"
+	BlockReasonBlocklist         BlockReason = "BLOCKLIST"
+	BlockReasonProhibitedContent BlockReason = "PROHIBITED_CONTENT"
+	BlockReasonImageSafety       BlockReason = "IMAGE_SAFETY"
+)
+
+// FinishReason describes why the model stopped generating a candidate.
+type FinishReason string
+
+// Constants for FinishReason
+const (
+	FinishReasonUnspecified FinishReason = "FINISH_REASON_UNSPECIFIED"
+	FinishReasonStop        FinishReason = "STOP"
+	FinishReasonMaxTokens   FinishReason = "MAX_TOKENS"
+	FinishReasonSafety      FinishReason = "SAFETY"
+	FinishReasonRecitation  FinishReason = "RECITATION"
+	FinishReasonOther       FinishReason = "OTHER"
+
+	FinishReasonLanguage              FinishReason = "LANGUAGE"
+	FinishReasonBlocklist             FinishReason = "BLOCKLIST"
+	FinishReasonProhibitedContent     FinishReason = "PROHIBITED_CONTENT"
+	FinishReasonSPII                  FinishReason = "SPII"
+	FinishReasonMalformedFunctionCall FinishReason = "MALFORMED_FUNCTION_CALL"
+)
+
+// CandidateResult is one of the candidates generated for a request.
+type CandidateResult struct {
+	// Index is the candidate's position in the API response.
+	Index int `json:"index"`
+
+	// Text is the text generated for this candidate. It may be empty if generation was stopped,
+	// e.g., by safety filters.
+	Text string `json:"text"`
+
+	// ThoughtSummary is the summary of the model's thinking for this candidate, kept out of Text.
+	// It is empty unless thoughts are requested (see ThinkingConfig.IncludeThoughts).
+	ThoughtSummary string `json:"thought_summary,omitempty"`
+
+	// GroundingAttributions lists the sources cited by this candidate.
+	GroundingAttributions []GroundingAttribution `json:"grounding_attributions,omitempty"`
+
+	// FinishReason is why the model stopped generating this candidate.
+	FinishReason FinishReason `json:"finish_reason,omitempty"`
+
+	// FinishMessage explains FinishReason in more detail, if the API provided an explanation.
+	FinishMessage string `json:"finish_message,omitempty"`
+
+	// Logprobs holds the log probabilities of this candidate's tokens.
+	// It is nil unless GenerationParams.ResponseLogprobs is set.
+	Logprobs *Logprobs `json:"logprobs,omitempty"`
+}
+
+// PromptFeedback contains the API's assessment of the input prompt.
+type PromptFeedback struct {
+	// BlockReason is set when the prompt was blocked. It is empty otherwise.
+	BlockReason BlockReason `json:"block_reason,omitempty"`
+
+	// BlockReasonMessage is a human-readable explanation of the block, if provided.
+	// Note that the Gemini API backend does not populate this field.
+	BlockReasonMessage string `json:"block_reason_message,omitempty"`
+
+	// SafetyRatings lists the safety ratings for the prompt, one per category.
+	SafetyRatings []SafetyRating `json:"safety_ratings,omitempty"`
+}
+
+// newPromptFeedback converts the SDK prompt feedback into a library-owned PromptFeedback.
+func newPromptFeedback(fb *genai.GenerateContentResponsePromptFeedback) *PromptFeedback {
+	if fb == nil {
+		return nil
+	}
+	return &PromptFeedback{
+		BlockReason:        BlockReason(fb.BlockReason),
+		BlockReasonMessage: fb.BlockReasonMessage,
+		SafetyRatings:      newSafetyRatings(fb.SafetyRatings),
+	}
+}
+
 // ThinkingLevel controls the level of thinking the model should perform.
 // Recommended for Gemini 3/3.1 series models.
 type ThinkingLevel string
@@ -67,7 +222,8 @@ type ThinkingConfig struct {
 	// If true, thoughts are returned only if the model supports it and thoughts are available.
 	IncludeThoughts bool `json:"include_thoughts,omitempty"`
 	// ThinkingBudget indicates the thinking budget in tokens.
-	// Set to 0 to disable thinking. If nil, the model's default budget is used.
+	// Set to 0 to disable thinking, or to -1 to let the model adjust the budget to the
+	// complexity of the request. If nil, the model's default budget is used.
 	// Recommended for Gemini 2.5 series models.
 	ThinkingBudget *int32 `json:"thinking_budget,omitempty"`
 	// ThinkingLevel controls the level of thinking the model should perform.
@@ -97,6 +253,13 @@ func (tc *ThinkingConfig) toSDK() *genai.ThinkingConfig {
 // GroundingAttribution represents a source that the Gemini model used
 // to ground its generated content. This is a custom structure for your application.
 type GroundingAttribution struct {
+	// ID is a deterministic identifier derived from the canonical form of URL
+	// (see AttributionID). It is stable across runs, so it can be used to join
+	// sources from different responses. It is empty if URL is an unresolved grounding
+	// redirect URL, which differs between responses citing the same page; enable URL
+	// resolution (see WithNoRedirection) to get IDs for every source.
+	ID string `json:"id,omitempty"`
+
 	// Title of the web page or document from which the content was sourced.
 	Title string `json:"title,omitempty"`
 
@@ -108,6 +271,81 @@ type GroundingAttribution struct {
 
 	// Segments contains the text segment that was generated.
 	Segments []GroundingAttributionSegment `json:"segments,omitempty"`
+
+	// RetrievedAt is when the model's search retrieved the source, as reported by the API.
+	// The API does not expose per-source retrieval times, so this is the server-side creation
+	// time of the response. It is nil if the API did not report one (the Gemini API backend does not).
+	RetrievedAt *time.Time `json:"retrieved_at,omitempty"`
+
+	// ResolvedAt is when the library resolved the source's redirect URL to URL.
+	// It is nil if the URL was not resolved.
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+
+	// FetchedAt is when the library fetched the source's content.
+	// It is nil if the content was not fetched.
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
+
+	// HTTPStatus is the final HTTP status code of the source page, after redirects, as seen by
+	// source validation or enrichment (see WithSourceValidation). It is 0 if the source was not
+	// checked or could not be reached at all.
+	HTTPStatus int `json:"http_status,omitempty"`
+
+	// Reachable reports whether the source page responded with a successful (2xx or 3xx) status.
+	// It is only meaningful for checked sources; see HTTPStatus.
+	Reachable bool `json:"reachable,omitempty"`
+
+	// OffSite reports that the source is not on one of the sites the request was restricted to
+	// with GenerationParams.RestrictToSites.
+	OffSite bool `json:"off_site,omitempty"`
+
+	// ArchiveURL is the URL of an Internet Archive (Wayback Machine) snapshot of the source,
+	// set when the source no longer exists (see WithArchiveFallback).
+	ArchiveURL string `json:"archive_url,omitempty"`
+
+	// Snippet is the beginning of the main text of the source page (see WithSourceEnrichment).
+	Snippet string `json:"snippet,omitempty"`
+
+	// WordCount is the number of words in the main text of the source page (see WithSourceEnrichment).
+	WordCount int `json:"word_count,omitempty"`
+
+	// PublishedAt is when the source page was published, as declared by its metadata
+	// (article:published_time, JSON-LD datePublished, or the Last-Modified header).
+	// It is nil if the page was not fetched or declares no date (see WithSourceEnrichment).
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+
+	// CanonicalURL is the canonical URL the source page declares (<link rel="canonical">).
+	CanonicalURL string `json:"canonical_url,omitempty"`
+
+	// FaviconURL is the URL of the source site's icon.
+	FaviconURL string `json:"favicon_url,omitempty"`
+
+	// SiteName is the name of the source site (og:site_name).
+	SiteName string `json:"site_name,omitempty"`
+
+	// OGTitle and OGDescription are the OpenGraph title and description of the source page,
+	// for rendering source cards.
+	OGTitle       string `json:"og_title,omitempty"`
+	OGDescription string `json:"og_description,omitempty"`
+}
+
+// SourceDomain returns the lowercase domain of the source: the host of URL, or the reported
+// Domain while URL still points at the grounding redirect service. It returns an empty string if
+// neither is known, as is the case for unresolved URLs on the Gemini API.
+func (a GroundingAttribution) SourceDomain() string {
+	return attributionDomain(a)
+}
+
+// AccessedAt returns the most recent time the source is known to have been accessed,
+// by the library (FetchedAt, ResolvedAt) or by the model's search (RetrievedAt), for use as the
+// access date of a citation. It returns the zero time if none is known.
+func (a GroundingAttribution) AccessedAt() time.Time {
+	var latest time.Time
+	for _, t := range []*time.Time{a.RetrievedAt, a.ResolvedAt, a.FetchedAt} {
+		if t != nil && t.After(latest) {
+			latest = *t
+		}
+	}
+	return latest
 }
 
 // GroundingAttributionSegment represents a text segment within a grounding attribution.
@@ -124,27 +362,209 @@ type GroundingAttributionSegment struct {
 	// Text is the actual text segment that was generated.
 	Text string `json:"text,omitempty"`
 
-	// ConfidenceScore is the model's confidence score for this segment.
+	// ConfidenceScore is the model's confidence, in [0, 1], that the source supports this segment.
+	// Newer models (Gemini 2.5 and later) do not report confidence scores, leaving it zero.
 	ConfidenceScore float32 `json:"confidence_score,omitempty"`
 }
 
+// GroundingDetails mirrors the grounding metadata returned by the API for a candidate,
+// without the flattening applied to GroundingAttributions. It is intended for advanced
+// processing that needs the original chunk/support structure without depending on genai types.
+type GroundingDetails struct {
+	// Chunks lists the retrieved sources, in the order returned by the API.
+	// GroundingSupport.ChunkIndices refers to positions in this slice.
+	// URIs are reported as returned by the API, i.e., before any URL resolution.
+	Chunks []GroundingChunk `json:"chunks,omitempty"`
+
+	// Supports links segments of the generated text to the chunks that support them.
+	Supports []GroundingSupport `json:"supports,omitempty"`
+
+	// WebSearchQueries lists the web search queries the model issued.
+	WebSearchQueries []string `json:"web_search_queries,omitempty"`
+
+	// RetrievalQueries lists the queries executed by retrieval tools.
+	// Note that the Gemini API backend does not populate this field.
+	RetrievalQueries []string `json:"retrieval_queries,omitempty"`
+
+	// SearchEntryPoint holds the Google Search suggestion chips, if any.
+	SearchEntryPoint *SearchEntryPoint `json:"search_entry_point,omitempty"`
+
+	// DynamicRetrievalScore is the likelihood, in [0, 1], that Google Search could help answer
+	// the prompt. It is only populated when dynamic retrieval is enabled.
+	DynamicRetrievalScore float32 `json:"dynamic_retrieval_score,omitempty"`
+}
+
+// GroundingChunk is a single source retrieved for grounding.
+// Exactly one of Web and RetrievedContext is normally set.
+type GroundingChunk struct {
+	// Web is set for sources found by web search.
+	Web *GroundingChunkWeb `json:"web,omitempty"`
+
+	// RetrievedContext is set for sources found by retrieval tools (e.g., Vertex AI Search).
+	RetrievedContext *GroundingChunkRetrievedContext `json:"retrieved_context,omitempty"`
+}
+
+// GroundingChunkWeb is a web page retrieved for grounding.
+type GroundingChunkWeb struct {
+	// Title is the title of the page.
+	Title string `json:"title,omitempty"`
+
+	// URI is the page's URI (usually a grounding redirect URL).
+	URI string `json:"uri,omitempty"`
+
+	// Domain is the domain of the original page.
+	// Note that the Gemini API backend does not populate this field.
+	Domain string `json:"domain,omitempty"`
+}
+
+// GroundingChunkRetrievedContext is a document retrieved for grounding by a retrieval tool.
+type GroundingChunkRetrievedContext struct {
+	// Title is the title of the document.
+	Title string `json:"title,omitempty"`
+
+	// URI is the document's URI.
+	URI string `json:"uri,omitempty"`
+
+	// Text is the retrieved text of the document.
+	Text string `json:"text,omitempty"`
+
+	// DocumentName is the full resource name of the referenced Vertex AI Search document.
+	DocumentName string `json:"document_name,omitempty"`
+}
+
+// GroundingSupport links a segment of the generated text to the chunks that support it.
+type GroundingSupport struct {
+	// Segment is the supported segment of the generated text.
+	Segment *TextSegment `json:"segment,omitempty"`
+
+	// ChunkIndices lists the positions in GroundingDetails.Chunks of the supporting sources.
+	ChunkIndices []int `json:"chunk_indices,omitempty"`
+
+	// ConfidenceScores holds the confidence, in [0, 1], of each entry in ChunkIndices.
+	// Newer models leave it empty.
+	ConfidenceScores []float32 `json:"confidence_scores,omitempty"`
+}
+
+// TextSegment identifies a segment of the generated text.
+type TextSegment struct {
+	// PartIndex is the index of the content part the segment belongs to.
+	PartIndex int `json:"part_index,omitempty"`
+
+	// StartIndex is the inclusive byte offset of the segment within its part.
+	StartIndex int `json:"start_index,omitempty"`
+
+	// EndIndex is the exclusive byte offset of the segment within its part.
+	EndIndex int `json:"end_index,omitempty"`
+
+	// Text is the text of the segment.
+	Text string `json:"text,omitempty"`
+}
+
+// SearchEntryPoint holds the Google Search suggestion chips returned with grounded responses.
+type SearchEntryPoint struct {
+	// RenderedContent is the HTML and CSS snippet that renders the chips.
+	RenderedContent string `json:"rendered_content,omitempty"`
+
+	// SDKBlob is the JSON-encoded array of [query, url] tuples behind the chips.
+	SDKBlob []byte `json:"sdk_blob,omitempty"`
+}
+
 // Response is the structured output returned by methods like GenerateGroundedContent.
 // It contains the text generated by the model and any grounding information.
 type Response struct {
 	// GeneratedText is the primary textual content generated by the Gemini model.
 	GeneratedText string `json:"generated_text"`
 
+	// ThoughtSummary is the summary of the model's thinking that led to the answer, for debugging
+	// and inspection. It is kept separate from GeneratedText, so it does not leak into output
+	// shown to users, and is empty unless thoughts are requested with
+	// ThinkingConfig.IncludeThoughts and the model supports thinking.
+	ThoughtSummary string `json:"thought_summary,omitempty"`
+
 	// GroundingAttributions lists the sources that the model cited.
 	// These will be constructed by your application from the genai.GroundingMetadata
 	GroundingAttributions []GroundingAttribution `json:"grounding_attributions,omitempty"`
 
-	// SearchSuggestions contains a list of related search queries, if provided by the API or model.
-	// Note: Verify if and how the new genai SDK provides search suggestions. This field might need adjustment or removal.
+	// FinishReason is why the model stopped generating the first candidate, e.g., FinishReasonStop
+	// for a complete answer or FinishReasonMaxTokens for one cut off by the output token limit.
+	FinishReason FinishReason `json:"finish_reason,omitempty"`
+
+	// FinishMessage explains FinishReason in more detail, if the API provided an explanation.
+	FinishMessage string `json:"finish_message,omitempty"`
+
+	// SafetyRatings lists the safety ratings of the first candidate, one per harm category.
+	SafetyRatings []SafetyRating `json:"safety_ratings,omitempty"`
+
+	// Logprobs holds the log probabilities of the first candidate's tokens, for calibrating
+	// confidence in the answer. It is nil unless GenerationParams.ResponseLogprobs is set.
+	Logprobs *Logprobs `json:"logprobs,omitempty"`
+
+	// SearchSuggestions lists the web search queries the model used for grounding, which can be
+	// shown to users as related searches.
 	SearchSuggestions []string `json:"search_suggestions,omitempty"`
 
+	// SearchEntryPointHTML is the HTML and CSS of the Google Search suggestion chips returned with
+	// grounded responses. Google's grounding terms require displaying it alongside the answer
+	// when SearchSuggestions are shown. It is empty if the API returned none.
+	SearchEntryPointHTML string `json:"search_entry_point_html,omitempty"`
+
+	// URLContextMetadata lists the URLs retrieved by the URL Context tool and their retrieval status.
+	URLContextMetadata []URLContextMetadata `json:"url_context_metadata,omitempty"`
+
+	// GroundingDetails mirrors the full grounding metadata of the first candidate (chunks,
+	// supports, queries, and search entry point) for advanced processing.
+	// It is nil if the API returned no grounding metadata.
+	GroundingDetails *GroundingDetails `json:"grounding_details,omitempty"`
+
+	// FunctionCalls lists the user-defined function calls executed while generating the response,
+	// in the order the model requested them.
+	FunctionCalls []FunctionCallResult `json:"function_calls,omitempty"`
+
+	// Scope is the name of the search scope the response was produced with, if any.
+	Scope string `json:"scope,omitempty"`
+
+	// DomainDiversityMet reports whether the response cites at least the number of distinct
+	// domains required by WithMinDistinctDomains. It is nil if no requirement is configured.
+	DomainDiversityMet *bool `json:"domain_diversity_met,omitempty"`
+
+	// Partial reports that generation was stopped by the soft timeout (see WithSoftTimeout),
+	// so the text and grounding information are incomplete.
+	Partial bool `json:"partial,omitempty"`
+
+	// ExcludedAttributions lists the cited sources removed from GroundingAttributions because
+	// they are on a site excluded with GenerationParams.ExcludeSites.
+	ExcludedAttributions []GroundingAttribution `json:"excluded_attributions,omitempty"`
+
+	// StaleAttributions lists the cited sources removed from GroundingAttributions because they
+	// were published before the cutoff of GenerationParams.Freshness.
+	StaleAttributions []GroundingAttribution `json:"stale_attributions,omitempty"`
+
+	// DetectedLanguage is the ISO 639-1 code of the language the answer was detected to be written
+	// in, if GenerationParams.ResponseLanguage was set and the language could be detected.
+	DetectedLanguage string `json:"detected_language,omitempty"`
+
+	// LanguageMismatch reports that the answer is not written in GenerationParams.ResponseLanguage,
+	// even after a retry.
+	LanguageMismatch bool `json:"language_mismatch,omitempty"`
+
+	// SecurityFlags lists instruction-like content found in the cited sources or the generated
+	// text by the prompt-injection guard (see WithInjectionGuard). It is nil if nothing was found
+	// or the guard is disabled.
+	SecurityFlags []SecurityFlag `json:"security_flags,omitempty"`
+
+	// Attempts lists every generation request the client made to produce this response,
+	// including internal re-queries, in order. The first entry is the initial request.
+	Attempts []Attempt `json:"attempts,omitempty"`
+
+	// AllCandidates holds every candidate returned by the API, in order, when more than one
+	// was requested with GenerationParams.CandidateCount. The first entry corresponds to
+	// GeneratedText and GroundingAttributions. The sources of every candidate are filtered like
+	// GroundingAttributions. It is nil for single-candidate responses.
+	AllCandidates []CandidateResult `json:"all_candidates,omitempty"`
+
 	// PromptFeedback contains feedback regarding the safety ratings of the input prompt.
-	// This field will be populated from the new SDK's genai.PromptFeedback.
-	PromptFeedback *genai.GenerateContentResponsePromptFeedback `json:"prompt_feedback,omitempty"`
+	// It is nil if the API did not return any prompt feedback.
+	PromptFeedback *PromptFeedback `json:"prompt_feedback,omitempty"`
 
 	// Candidates gives access to all generated candidates from the model,
 	// including safety ratings and other metadata. Typically, GeneratedText
@@ -159,15 +579,78 @@ type Response struct {
 	RawResponse *genai.GenerateContentResponse `json:"-"`
 }
 
+// URLRetrievalStatus is the outcome of retrieving a URL with the URL Context tool.
+type URLRetrievalStatus string
+
+// Constants for URLRetrievalStatus
+const (
+	URLRetrievalStatusUnspecified URLRetrievalStatus = "URL_RETRIEVAL_STATUS_UNSPECIFIED"
+	URLRetrievalStatusSuccess     URLRetrievalStatus = "URL_RETRIEVAL_STATUS_SUCCESS"
+	URLRetrievalStatusError       URLRetrievalStatus = "URL_RETRIEVAL_STATUS_ERROR"
+	URLRetrievalStatusPaywall     URLRetrievalStatus = "URL_RETRIEVAL_STATUS_PAYWALL"
+	URLRetrievalStatusUnsafe      URLRetrievalStatus = "URL_RETRIEVAL_STATUS_UNSAFE"
+)
+
+// URLContextMetadata describes a URL retrieved by the URL Context tool.
+type URLContextMetadata struct {
+	// URL is the retrieved URL.
+	URL string `json:"url"`
+
+	// Status is the outcome of the retrieval.
+	Status URLRetrievalStatus `json:"status,omitempty"`
+}
+
 // --- Request Parameter Types ---
 
+// PromptParts is the structured form of a prompt. Every non-empty part is sent in its own
+// delimited section, in the order SystemConstraints, Context, Question.
+type PromptParts struct {
+	// SystemConstraints are rules the answer must follow (e.g., "answer in three bullet points",
+	// "cite only primary sources"). They are presented as constraints, not as part of the question.
+	SystemConstraints string `json:"system_constraints,omitempty"`
+
+	// Context is background information the application provides for the question
+	// (e.g., the user's role or the product the question is about).
+	Context string `json:"context,omitempty"`
+
+	// Question is the question to answer.
+	Question string `json:"question,omitempty"`
+}
+
+// UserLocation is where the user is, so that searches prefer locale-relevant sources and units.
+type UserLocation struct {
+	// CountryCode is the ISO 3166-1 alpha-2 code of the user's country (e.g., "JP", "DE").
+	CountryCode string `json:"country_code,omitempty"`
+
+	// City is the user's city (e.g., "Osaka"). Optional.
+	City string `json:"city,omitempty"`
+}
+
+// AudioInput is an audio clip sent with a request, such as a spoken question.
+type AudioInput struct {
+	// Data is the audio clip.
+	Data []byte `json:"data"`
+
+	// MIMEType is the MIME type of Data (e.g., "audio/wav"). If empty, it is detected from
+	// the content for WAV, MP3, AIFF, AAC, OGG, and FLAC.
+	MIMEType string `json:"mime_type,omitempty"`
+}
+
 // GenerationParams defines the parameters for a grounded content generation request.
 // These parameters will generally be mapped to the new SDK's genai.GenerationConfig struct.
 type GenerationParams struct {
 	// Prompt is the input text or query for the model.
+	// It can be left empty when the question is given in PromptParts or spoken in Audio.
 	Prompt string `json:"prompt"`
 
+	// PromptParts is an optional structured form of the prompt. The library assembles its parts
+	// with clear delimiters, so constraints and context are not mistaken for the question.
+	// Prompt is used as the question if PromptParts.Question is empty.
+	PromptParts *PromptParts `json:"prompt_parts,omitempty"`
+
 	// ModelName specifies the Gemini model to use for the request.
+	// Plain model IDs and fully qualified resource names, including tuned models
+	// (e.g., "tunedModels/my-model"), are accepted.
 	// If empty, a default model specified at the client level will be used.
 	ModelName string `json:"model_name,omitempty"` // This is usually part of the model client, not GenerationConfig.
 
@@ -187,6 +670,29 @@ type GenerationParams struct {
 	// Corresponds to genai.GenerationConfig.TopP.
 	TopP *float32 `json:"top_p,omitempty"`
 
+	// Seed fixes the random seed used for decoding, overriding the client-level default, so that
+	// repeated requests produce the same output where the model supports it.
+	// Corresponds to genai.GenerationConfig.Seed.
+	Seed *int32 `json:"seed,omitempty"`
+
+	// PresencePenalty penalizes tokens that already appear in the generated text, encouraging
+	// the model to cover new content. Positive values penalize, negative values encourage reuse.
+	// Corresponds to genai.GenerationConfig.PresencePenalty.
+	PresencePenalty *float32 `json:"presence_penalty,omitempty"`
+
+	// FrequencyPenalty penalizes tokens in proportion to how often they already appear in the
+	// generated text, reducing repetition. Corresponds to genai.GenerationConfig.FrequencyPenalty.
+	FrequencyPenalty *float32 `json:"frequency_penalty,omitempty"`
+
+	// ResponseLogprobs requests the log probabilities of the generated tokens, returned in
+	// Response.Logprobs. Corresponds to genai.GenerationConfig.ResponseLogprobs.
+	ResponseLogprobs bool `json:"response_logprobs,omitempty"`
+
+	// Logprobs is the number of most likely tokens, from 0 to 20, whose log probabilities are
+	// returned for each decoding step. It requires ResponseLogprobs.
+	// Corresponds to genai.GenerationConfig.Logprobs.
+	Logprobs *int32 `json:"logprobs,omitempty"`
+
 	// CandidateCount is the number of generated response messages to return.
 	// For Gemini API's non-streaming GenerateContent, this is typically 1.
 	// Corresponds to genai.GenerationConfig.CandidateCount.
@@ -204,4 +710,118 @@ type GenerationParams struct {
 
 	// ThinkingConfig overrides the client-level thinking configuration for this request.
 	ThinkingConfig *ThinkingConfig `json:"thinking_config,omitempty"`
+
+	// ThinkingBudgetTokens sets the thinking budget in tokens for this request, e.g., a larger
+	// budget for hard multi-hop questions, 0 to disable thinking for simple lookups, or -1 to
+	// let the model adjust the budget to the question (dynamic thinking). It overrides the
+	// budget and level of ThinkingConfig or the client-level thinking configuration, whose
+	// other settings are kept.
+	ThinkingBudgetTokens *int32 `json:"thinking_budget_tokens,omitempty"`
+
+	// Scope selects a search scope registered with WithSearchScopes. The scope's guidance is added
+	// to SearchGuidance and its allowed domains are enforced on the attributions.
+	Scope string `json:"scope,omitempty"`
+
+	// SearchGuidance steers source selection for this request (e.g., "prefer official
+	// government statistics"). It is added to the prompt in a dedicated, delimited section
+	// kept separate from Prompt, so it is not mistaken for part of the question.
+	SearchGuidance string `json:"search_guidance,omitempty"`
+
+	// CachedContent overrides the client-level cached content for this request
+	// (see WithCachedContent and Client.CreateCachedContent).
+	CachedContent string `json:"cached_content,omitempty"`
+
+	// Freshness restricts the answer to sources published recently. It is compiled into the
+	// prompt and, on the Gemini API, into a time range filter of the Google Search Tool. Cited
+	// sources are then fetched to check their publication dates (as with WithSourceEnrichment),
+	// and those published before the cutoff are moved to Response.StaleAttributions. Sources
+	// without a detectable publication date are kept. It cannot be used with cached content.
+	Freshness *Freshness `json:"freshness,omitempty"`
+
+	// ResponseLanguage is the language the answer must be written in, as a language tag such as
+	// "en" or "ja-JP". The model is instructed accordingly, and answers in Arabic, Chinese, Dutch,
+	// English, French, German, Greek, Hebrew, Hindi, Italian, Japanese, Korean, Portuguese,
+	// Russian, Spanish, Thai, or Ukrainian are checked: if one comes back in another language,
+	// the request is retried once. See Response.LanguageMismatch.
+	ResponseLanguage string `json:"response_language,omitempty"`
+
+	// UserLocation overrides the client-level user location for this request (see WithUserLocation).
+	// Set it to a zero UserLocation to send no location.
+	UserLocation *UserLocation `json:"user_location,omitempty"`
+
+	// MinConfidence overrides the client-level minimum grounding confidence for this request
+	// (see WithMinGroundingConfidence). Set it to 0 to keep every attribution.
+	MinConfidence *float32 `json:"min_confidence,omitempty"`
+
+	// RestrictToSites limits searching to these sites and their subdomains (e.g., "go.dev"). The
+	// model is instructed to search them with site: operators and to cite only their pages. Cited
+	// sources from other sites are kept but marked with GroundingAttribution.OffSite; to remove
+	// them, set AllowedDomains as well. URL resolution is enabled, so that the sites of the
+	// sources are known.
+	RestrictToSites []string `json:"restrict_to_sites,omitempty"`
+
+	// ExcludeSites lists sites that must never be cited (e.g., "reddit.com"), including their
+	// subdomains. The model is instructed to avoid them, Vertex AI also excludes them from the
+	// search itself, and cited sources from them are moved to Response.ExcludedAttributions.
+	// URL resolution is enabled, so that the sites of the sources are known. It cannot be used
+	// with cached content.
+	ExcludeSites []string `json:"exclude_sites,omitempty"`
+
+	// AllowedDomains overrides the client-level allowed domains for this request
+	// (see WithAllowedDomains). Set it to an empty, non-nil slice to allow every domain.
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+
+	// BlockedDomains overrides the client-level blocked domains for this request
+	// (see WithBlockedDomains). Set it to an empty, non-nil slice to block no domain.
+	BlockedDomains []string `json:"blocked_domains,omitempty"`
+
+	// ResolveURLs overrides the client-level NoRedirection setting for this request.
+	// Set it to false to skip URL resolution on latency-sensitive calls, or to true to
+	// resolve original source URLs. If nil, the client setting is used. Requests whose sources
+	// are filtered by domain always resolve URLs; setting it to false for them is an error.
+	ResolveURLs *bool `json:"resolve_urls,omitempty"`
+
+	// ContextMaterial is supplementary material the question is about (e.g., the user's notes).
+	// It is added to the prompt in a dedicated, delimited section so the model answers the question
+	// about it, using web sources for current information, without treating it as instructions.
+	ContextMaterial string `json:"context_material,omitempty"`
+
+	// Images are images the question is about (e.g., "what model of aircraft is this?"), sent
+	// inline after the prompt. Their format (PNG, JPEG, GIF, or WebP) is detected from their content.
+	Images [][]byte `json:"images,omitempty"`
+
+	// ImageURIs are images the question is about, given by URI (e.g., a Cloud Storage "gs://" URI
+	// on Vertex AI). Their MIME type is derived from the file extension.
+	ImageURIs []string `json:"image_uris,omitempty"`
+
+	// Audio is an audio clip sent with the prompt, such as a spoken question for a voice
+	// assistant. If Prompt (or PromptParts.Question) is empty, the model answers the question
+	// asked in the audio, with grounding still enabled.
+	Audio *AudioInput `json:"audio,omitempty"`
+
+	// Files are documents (e.g., PDFs uploaded with Client.UploadFile) the answer should draw on
+	// alongside fresh web results, e.g., "check whether this contract's cited regulation is still current".
+	Files []File `json:"files,omitempty"`
+
+	// ContextURLs lists pages the answer should be grounded in. They are appended to the prompt
+	// and read by the URL Context tool, which is enabled for the request if it is not already
+	// enabled on the client.
+	ContextURLs []string `json:"context_urls,omitempty"`
+
+	// Tools lists additional SDK tools for this request, appended to the client's tools.
+	Tools []*genai.Tool `json:"-"`
+
+	// Functions lists additional user-defined functions the model may call for this request,
+	// on top of those registered on the client. Function names must not collide.
+	Functions []Function `json:"-"`
+
+	// ResponseMIMEType overrides the client-level MIME type of the generated text
+	// (e.g., "application/json", "text/plain").
+	// Corresponds to genai.GenerateContentConfig.ResponseMIMEType.
+	ResponseMIMEType string `json:"response_mime_type,omitempty"`
+
+	// disableSearch sends the request without the web search tools, for internal steps that must
+	// only use the text they are given (see Client.GroundedSummarize). Requests using cached
+	// content keep the tools of the cache.
+	disableSearch bool
 }
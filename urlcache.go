@@ -0,0 +1,46 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"github.com/cnosuke/go-gemini-grounded-search/store"
+)
+
+// urlCachePrefix is the store key prefix of cached URL resolutions.
+const urlCachePrefix = "url-resolution/"
+
+// urlCache caches resolved grounding redirect URLs so that sources cited again in later
+// responses are not resolved again.
+type urlCache struct {
+	store store.Store
+	ttl   time.Duration
+}
+
+// newURLCache creates a urlCache backed by s. It returns nil, disabling caching, if s is nil.
+func newURLCache(s store.Store, ttl time.Duration) *urlCache {
+	if s == nil {
+		return nil
+	}
+	return &urlCache{store: s, ttl: ttl}
+}
+
+// get returns the cached resolution of rawURL and whether it was found.
+func (c *urlCache) get(ctx context.Context, rawURL string) (string, bool, error) {
+	if c == nil {
+		return "", false, nil
+	}
+	b, ok, err := c.store.Get(ctx, urlCachePrefix+rawURL)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
+
+// set caches origin as the resolution of rawURL.
+func (c *urlCache) set(ctx context.Context, rawURL, origin string) error {
+	if c == nil {
+		return nil
+	}
+	return c.store.Set(ctx, urlCachePrefix+rawURL, []byte(origin), c.ttl)
+}
@@ -0,0 +1,74 @@
+package search
+
+import (
+	"context"
+	"net/http"
+
+	ierrors "github.com/cnosuke/go-gemini-grounded-search/internal/errors"
+)
+
+// checkSourceStatus returns the final HTTP status code and URL of urlStr, following redirects.
+// It sends a HEAD request, falling back to GET for servers that do not support HEAD.
+func checkSourceStatus(ctx context.Context, customClient *http.Client, header http.Header, urlStr string) (int, string, error) {
+	client := &http.Client{Timeout: sourceFetchTimeout}
+	if customClient != nil {
+		client.Transport = customClient.Transport
+	}
+
+	var status int
+	finalURL := urlStr
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
+		if err != nil {
+			return 0, "", ierrors.Wrapf(err, "failed to create request for %s", urlStr)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, "", ierrors.Wrapf(err, "failed to send %s request to %s", method, urlStr)
+		}
+		resp.Body.Close() // The body is not needed; closing it early aborts the download.
+		status = resp.StatusCode
+		finalURL = resp.Request.URL.String()
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			break
+		}
+	}
+	return status, finalURL, nil
+}
+
+// validateSource records the HTTP status of the source page of attr.
+func (c *Client) validateSource(ctx context.Context, attr *GroundingAttribution) error {
+	target, err := c.sourceURL(ctx, attr.URL)
+	if err != nil {
+		attr.Reachable = false
+		return err
+	}
+	release, err := c.hostLimiter.acquire(ctx, target)
+	if err != nil {
+		return err
+	}
+	status, finalURL, err := checkSourceStatus(ctx, c.resolverClient, c.resolverHeader, target)
+	release()
+	if err != nil {
+		attr.Reachable = false
+		return err
+	}
+	return c.recordStatus(ctx, attr, status, finalURL)
+}
+
+// recordStatus records the HTTP status of the source page of attr, fetched from finalURL after
+// following redirects, and, if the source no longer exists and the archive fallback is enabled,
+// attaches an archived copy of finalURL.
+func (c *Client) recordStatus(ctx context.Context, attr *GroundingAttribution, status int, finalURL string) error {
+	attr.HTTPStatus = status
+	attr.Reachable = status >= 200 && status <= 399
+	if isGone(status) && c.config.ArchiveFallback {
+		if err := c.attachArchiveURL(ctx, attr, finalURL); err != nil {
+			return ierrors.Wrapf(err, "source %s is gone (status %d); failed to look up an archived copy", finalURL, status)
+		}
+	}
+	return nil
+}